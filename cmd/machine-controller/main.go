@@ -4,8 +4,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned/scheme"
 	_ "github.com/gardener/machine-controller-manager/pkg/util/client/metrics/prometheus" // for client metric registration
@@ -14,21 +16,28 @@ import (
 	_ "github.com/gardener/machine-controller-manager/pkg/util/reflector/prometheus" // for reflector metric registration
 	_ "github.com/gardener/machine-controller-manager/pkg/util/workqueue/prometheus" // for workqueue metric registration
 	computev1alpha1 "github.com/ironcore-dev/ironcore/api/compute/v1alpha1"
+	networkingv1alpha1 "github.com/ironcore-dev/ironcore/api/networking/v1alpha1"
+	storagev1alpha1 "github.com/ironcore-dev/ironcore/api/storage/v1alpha1"
 	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/ironcore"
 	"github.com/spf13/pflag"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/rest"
 	"k8s.io/component-base/cli/flag"
 	"k8s.io/component-base/logs"
 	logsv1 "k8s.io/component-base/logs/api/v1"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var (
-	IroncoreKubeconfigPath string
-	CSIDriverName          string
+	IroncoreKubeconfigPath           string
+	IroncoreKubeconfigReloadInterval time.Duration
+	IroncoreClientCache              bool
+	IroncoreClientQPS                float32
+	IroncoreClientBurst              int
+	IroncoreClientTimeout            time.Duration
+	IroncoreClientUserAgent          string
+	CSIDriverName                    string
 )
 
 func main() {
@@ -48,13 +57,23 @@ func main() {
 		os.Exit(1)
 	}
 
-	ironcoreClient, namespace, err := getIroncoreClientAndNamespace()
+	clientOpts := ironcore.ClientOptions{
+		Cache:     IroncoreClientCache,
+		QPS:       IroncoreClientQPS,
+		Burst:     IroncoreClientBurst,
+		Timeout:   IroncoreClientTimeout,
+		UserAgent: IroncoreClientUserAgent,
+	}
+	ironcoreClient, namespace, err := getIroncoreClientAndNamespace(clientOpts)
 	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
+	if IroncoreKubeconfigReloadInterval > 0 {
+		go ironcoreClient.Start(context.Background(), IroncoreKubeconfigReloadInterval)
+	}
 
-	drv := ironcore.NewDriver(ironcoreClient, namespace, CSIDriverName)
+	drv := ironcore.NewDriver(ironcoreClient, namespace, CSIDriverName, clientOpts)
 	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
@@ -66,43 +85,31 @@ func main() {
 	}
 }
 
-func getIroncoreClientAndNamespace() (client.Client, string, error) {
+func getIroncoreClientAndNamespace(clientOpts ironcore.ClientOptions) (*ironcore.ReloadingClient, string, error) {
 	s := runtime.NewScheme()
 	utilruntime.Must(scheme.AddToScheme(s))
 	utilruntime.Must(computev1alpha1.AddToScheme(s))
+	utilruntime.Must(networkingv1alpha1.AddToScheme(s))
+	utilruntime.Must(storagev1alpha1.AddToScheme(s))
 	utilruntime.Must(corev1.AddToScheme(s))
 
-	ironcoreKubeconfigData, err := os.ReadFile(IroncoreKubeconfigPath)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to read ironcore kubeconfig %s: %w", IroncoreKubeconfigPath, err)
-	}
-	ironcoreKubeconfig, err := clientcmd.Load(ironcoreKubeconfigData)
-	if err != nil {
-		return nil, "", fmt.Errorf("unable to read ironcore cluster kubeconfig: %w", err)
-	}
-	clientConfig := clientcmd.NewDefaultClientConfig(*ironcoreKubeconfig, nil)
+	client, namespace, err := ironcore.NewReloadingClient(context.Background(), IroncoreKubeconfigPath, s, clientOpts)
 	if err != nil {
-		return nil, "", fmt.Errorf("unable to serialize ironcore cluster kubeconfig: %w", err)
-	}
-	restConfig, err := clientConfig.ClientConfig()
-	if err != nil {
-		return nil, "", fmt.Errorf("unable to get ironcore cluster rest config: %w", err)
-	}
-	namespace, _, err := clientConfig.Namespace()
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to get namespace from ironcore kubeconfig: %w", err)
+		return nil, "", err
 	}
 	if namespace == "" {
 		return nil, "", fmt.Errorf("got a empty namespace from ironcore kubeconfig")
 	}
-	client, err := client.New(restConfig, client.Options{Scheme: s})
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to create client: %w", err)
-	}
 	return client, namespace, nil
 }
 
 func AddExtraFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&IroncoreKubeconfigPath, "ironcore-kubeconfig", "", "Path to the ironcore kubeconfig.")
+	fs.DurationVar(&IroncoreKubeconfigReloadInterval, "ironcore-kubeconfig-reload-interval", 30*time.Second, "Interval in which the ironcore kubeconfig is checked for changes, e.g. rotated credentials. 0 disables reloading.")
+	fs.BoolVar(&IroncoreClientCache, "ironcore-client-cache", false, "Serve reads of ironcore Machines, Secrets and MachinePools from an informer cache instead of the ironcore apiserver. Clients for credentials of machine class secrets are not cached.")
+	fs.Float32Var(&IroncoreClientQPS, "ironcore-client-qps", rest.DefaultQPS, "Maximum number of requests per second to the ironcore apiserver. A negative value disables the rate limiter.")
+	fs.IntVar(&IroncoreClientBurst, "ironcore-client-burst", rest.DefaultBurst, "Number of requests to the ironcore apiserver that may exceed the QPS.")
+	fs.DurationVar(&IroncoreClientTimeout, "ironcore-client-timeout", 0, "Timeout of a single request to the ironcore apiserver, watches of the client cache are not affected. 0 means no timeout.")
+	fs.StringVar(&IroncoreClientUserAgent, "ironcore-client-user-agent", "", "User agent of requests to the ironcore apiserver. Defaults to the client-go user agent.")
 	fs.StringVar(&CSIDriverName, "csi-driver-name", ironcore.DefaultCSIDriverName, "CSI driver name used to determine the volumes for a Node.")
 }
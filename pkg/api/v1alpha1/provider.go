@@ -4,9 +4,11 @@
 package v1alpha1
 
 import (
+	"fmt"
 	"net/netip"
 
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -14,11 +16,32 @@ const (
 	V1Alpha1 = "mcm.gardener.cloud/v1alpha1"
 	// ProviderName is the provider name
 	ProviderName = "ironcore"
+	// RootDiskVolumeName is the name of the Machine volume containing the operating system
+	RootDiskVolumeName = "root"
+	// PrimaryNetworkInterfaceName is the name of the network interface which is requested for every Machine
+	PrimaryNetworkInterfaceName = "nic"
+)
+
+// Keys of the machine class secret selecting the ironcore cluster and namespace the machines are created in. Without
+// them, the ironcore kubeconfig of the machine controller is used.
+const (
+	// SecretKubeconfigKey contains a kubeconfig of the ironcore cluster. Its namespace is used unless
+	// SecretNamespaceKey is set. It must not be set together with SecretTokenKey.
+	SecretKubeconfigKey = "kubeconfig"
+	// SecretTokenKey contains a bearer token for the ironcore cluster at SecretServerKey.
+	SecretTokenKey = "token"
+	// SecretServerKey contains the https URL of the ironcore API server. It is required with SecretTokenKey.
+	SecretServerKey = "server"
+	// SecretCAKey contains the PEM encoded CA bundle of the ironcore API server for SecretServerKey.
+	SecretCAKey = "ca.crt"
+	// SecretNamespaceKey contains the ironcore namespace the machines are created in.
+	SecretNamespaceKey = "namespace"
 )
 
 // ProviderSpec is the spec to be used while parsing the calls
 type ProviderSpec struct {
-	// Image is the URL pointing to an OCI registry containing the operating system image which should be used to boot the Machine
+	// Image is the URL pointing to an OCI registry containing the operating system image which should be used to boot the Machine.
+	// It must not be set if the root disk is created from a VolumeSnapshot.
 	Image string `json:"image,omitempty"`
 	// Ignition contains the ignition configuration which should be run on first boot of a Machine.
 	Ignition string `json:"ignition,omitempty"`
@@ -28,18 +51,212 @@ type ProviderSpec struct {
 	// IgnitionSecretKey is optional key field used to identify the ignition content in the Secret
 	// If the key is empty, the DefaultIgnitionKey will be used as fallback.
 	IgnitionSecretKey string `json:"ignitionSecretKey,omitempty"`
+	// IgnitionStrict defines whether warnings reported while rendering the ignition are treated as errors.
+	IgnitionStrict bool `json:"ignitionStrict,omitempty"`
 	// RootDisk defines the root disk properties of the Machine.
 	RootDisk *RootDisk `json:"rootDisk,omitempty"`
+	// DataDisks is a list of additional volumes which are attached to the Machine.
+	DataDisks []DataDisk `json:"dataDisks,omitempty"`
+	// Filesystems is a list of filesystems which should be created on the data disks of the Machine.
+	// Filesystems are only supported with BootstrapFormatIgnition.
+	Filesystems []Filesystem `json:"filesystems,omitempty"`
+	// KernelArguments is a list of kernel arguments which should be present on the host, e.g. "hugepages=1024".
+	// Kernel arguments are only supported with BootstrapFormatIgnition.
+	KernelArguments []string `json:"kernelArguments,omitempty"`
 	// NetworkName is the Network to be used for the Machine's NetworkInterface.
 	NetworkName string `json:"networkName"`
 	// PrefixName is the parent Prefix from which an IP should be allocated for the Machine's NetworkInterface.
 	PrefixName string `json:"prefixName"`
 	// Labels are used to tag resources which the MCM creates, so they can be identified later.
+	// They are set on every ironcore object created for a machine, together with labels identifying the machine.
 	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are set on every ironcore object created for a machine.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Naming defines the names of the ironcore objects of a machine, which are also used as node names.
+	// Defaults to the machine name.
+	Naming *MachineNaming `json:"naming,omitempty"`
 	// DnsServers is a list of DNS resolvers which should be configured on the host.
+	// Deprecated: Use DNS.Servers instead.
 	DnsServers []netip.Addr `json:"dnsServers,omitempty"`
+	// DNS defines the DNS resolver configuration of the host.
+	DNS *DNS `json:"dns,omitempty"`
+	// NetworkInterfaces defines the host configuration of the network interfaces of the Machine.
+	NetworkInterfaces []NetworkInterface `json:"networkInterfaces,omitempty"`
+	// SSHAuthorizedKeys is a list of SSH public keys which are authorized to log in as the default user of the image.
+	SSHAuthorizedKeys []string `json:"sshAuthorizedKeys,omitempty"`
+	// Users is a list of additional users which should be created on the host.
+	Users []User `json:"users,omitempty"`
+	// CACertificates is a list of PEM encoded CA certificates which should be trusted by the host.
+	CACertificates []string `json:"caCertificates,omitempty"`
+	// NTP defines the time synchronization of the host.
+	NTP *NTP `json:"ntp,omitempty"`
+	// Proxy defines the HTTP(S) proxy which should be used by the host.
+	Proxy *Proxy `json:"proxy,omitempty"`
+	// Sysctls is a map of kernel parameters which should be set on the host.
+	Sysctls map[string]string `json:"sysctls,omitempty"`
+	// Bootstrap defines how the bootstrap script from the userData is run on the host.
+	// It is not used with BootstrapFormatRaw.
+	Bootstrap *Bootstrap `json:"bootstrap,omitempty"`
+	// BootstrapFormat defines in which format the bootstrap data is handed over to the Machine.
+	// If the format is empty, BootstrapFormatIgnition will be used as fallback.
+	BootstrapFormat BootstrapFormat `json:"bootstrapFormat,omitempty"`
+}
+
+// User defines a user which should be created on the host.
+type User struct {
+	// Name is the name of the user.
+	Name string `json:"name"`
+	// Groups is a list of supplementary groups of the user. The groups have to exist on the host.
+	Groups []string `json:"groups,omitempty"`
+	// SSHAuthorizedKeys is a list of SSH public keys which are authorized to log in as the user.
+	SSHAuthorizedKeys []string `json:"sshAuthorizedKeys,omitempty"`
+	// Shell is the absolute path of the login shell of the user.
+	Shell string `json:"shell,omitempty"`
+}
+
+// DNS defines the DNS resolver configuration of the host.
+type DNS struct {
+	// Servers is a list of DNS resolvers which are used for all interfaces of the host.
+	Servers []netip.Addr `json:"servers,omitempty"`
+	// SearchDomains is a list of domains which are used to complete single-label host names.
+	// Domains prefixed with "~" are only used for routing DNS queries and not for completion.
+	SearchDomains []string `json:"searchDomains,omitempty"`
+	// FallbackServers is a list of DNS resolvers which are used if no other resolver is known.
+	FallbackServers []netip.Addr `json:"fallbackServers,omitempty"`
+	// DNSSEC defines whether DNS responses are validated with DNSSEC.
+	DNSSEC DNSSECMode `json:"dnssec,omitempty"`
+	// DNSOverTLS defines whether DNS queries are sent encrypted via TLS.
+	DNSOverTLS DNSOverTLSMode `json:"dnsOverTLS,omitempty"`
+	// Interfaces is a list of DNS configurations which only apply to a single interface of the host.
+	Interfaces []InterfaceDNS `json:"interfaces,omitempty"`
+}
+
+// InterfaceDNS defines the DNS resolver configuration of a single interface of the host. Like the NetworkInterface
+// configuration it replaces the systemd-networkd units of the image for the interface.
+type InterfaceDNS struct {
+	// Name is the name of the host interface. Shell-style globs are supported.
+	Name string `json:"name"`
+	// Servers is a list of DNS resolvers which are used for the interface.
+	Servers []netip.Addr `json:"servers,omitempty"`
+	// SearchDomains is a list of domains which are resolved via the interface.
+	SearchDomains []string `json:"searchDomains,omitempty"`
+}
+
+// NetworkInterface defines the host configuration of a network interface of the Machine.
+// The interface keeps being addressed via DHCP. The configuration is written as systemd-networkd unit which takes
+// precedence over the units of the image and fully replaces them for the interface, so settings of the image for
+// the interface are not applied.
+type NetworkInterface struct {
+	// Name is the name of the network interface of the Machine, e.g. PrimaryNetworkInterfaceName.
+	Name string `json:"name"`
+	// HostInterfaceName is the name of the interface on the host. Shell-style globs are supported.
+	HostInterfaceName string `json:"hostInterfaceName"`
+	// MTU is the maximum transmission unit of the interface in bytes.
+	MTU *int32 `json:"mtu,omitempty"`
+	// Routes is a list of static routes which are added to the interface.
+	Routes []Route `json:"routes,omitempty"`
+	// VLANs is a list of VLANs which are created on top of the interface.
+	VLANs []VLAN `json:"vlans,omitempty"`
+}
+
+// Route defines a static route.
+type Route struct {
+	// Destination is the destination prefix of the route.
+	Destination netip.Prefix `json:"destination"`
+	// Gateway is the gateway of the route. If it is empty the destination is directly reachable via the interface.
+	Gateway *netip.Addr `json:"gateway,omitempty"`
+	// Metric is the metric of the route.
+	Metric *uint32 `json:"metric,omitempty"`
+}
+
+// VLAN defines a VLAN interface on the host.
+type VLAN struct {
+	// ID is the VLAN tag of the interface.
+	ID int32 `json:"id"`
+	// Name is the name of the VLAN interface on the host. Defaults to "vlan<ID>".
+	Name string `json:"name,omitempty"`
+	// MTU is the maximum transmission unit of the VLAN interface in bytes.
+	MTU *int32 `json:"mtu,omitempty"`
+	// Addresses is a list of static addresses of the VLAN interface. If it is empty the VLAN interface is
+	// addressed via DHCP.
+	Addresses []netip.Prefix `json:"addresses,omitempty"`
+	// Routes is a list of static routes which are added to the VLAN interface.
+	Routes []Route `json:"routes,omitempty"`
+}
+
+// HostInterfaceName returns the name of the VLAN interface on the host.
+func (v VLAN) HostInterfaceName() string {
+	if v.Name != "" {
+		return v.Name
+	}
+	return fmt.Sprintf("vlan%d", v.ID)
 }
 
+// DNSSECMode defines whether DNS responses are validated with DNSSEC.
+type DNSSECMode string
+
+const (
+	// DNSSECModeEnabled validates all DNS responses and fails on missing or invalid signatures.
+	DNSSECModeEnabled DNSSECMode = "Enabled"
+	// DNSSECModeDisabled disables the DNSSEC validation.
+	DNSSECModeDisabled DNSSECMode = "Disabled"
+	// DNSSECModeAllowDowngrade validates DNS responses if the resolver supports DNSSEC.
+	DNSSECModeAllowDowngrade DNSSECMode = "AllowDowngrade"
+)
+
+// DNSOverTLSMode defines whether DNS queries are sent encrypted via TLS.
+type DNSOverTLSMode string
+
+const (
+	// DNSOverTLSModeEnabled sends all DNS queries via TLS and fails if the resolver does not support it.
+	DNSOverTLSModeEnabled DNSOverTLSMode = "Enabled"
+	// DNSOverTLSModeDisabled sends all DNS queries unencrypted.
+	DNSOverTLSModeDisabled DNSOverTLSMode = "Disabled"
+	// DNSOverTLSModeOpportunistic sends DNS queries via TLS if the resolver supports it.
+	DNSOverTLSModeOpportunistic DNSOverTLSMode = "Opportunistic"
+)
+
+// NTP defines the time synchronization of the host.
+type NTP struct {
+	// Servers is a list of NTP servers given as hostnames or IP addresses.
+	Servers []string `json:"servers"`
+}
+
+// Proxy defines the HTTP(S) proxy which should be used by the host.
+type Proxy struct {
+	// HTTPProxy is the URL of the proxy used for HTTP requests.
+	HTTPProxy string `json:"httpProxy,omitempty"`
+	// HTTPSProxy is the URL of the proxy used for HTTPS requests.
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+	// NoProxy is a list of hosts, domains and CIDRs which should be accessed without proxy.
+	NoProxy []string `json:"noProxy,omitempty"`
+}
+
+// Bootstrap defines how the bootstrap script from the userData is run on the host.
+type Bootstrap struct {
+	// MaxRetries is the maximum number of times the bootstrap script is retried after it failed. If it is not set the
+	// bootstrap script is retried until it succeeds.
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
+	// RetryInterval is the time to wait before the bootstrap script is retried. Defaults to 5s.
+	RetryInterval *metav1.Duration `json:"retryInterval,omitempty"`
+	// Timeout is the maximum duration of a single run of the bootstrap script. If it is not set the run is not limited.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// CallbackURL is an HTTP(S) endpoint the bootstrap progress is posted to as JSON.
+	CallbackURL string `json:"callbackURL,omitempty"`
+}
+
+// BootstrapFormat is the format of the bootstrap data which is handed over to the Machine.
+type BootstrapFormat string
+
+const (
+	// BootstrapFormatIgnition renders the userData together with the host configuration into an Ignition config.
+	BootstrapFormatIgnition BootstrapFormat = "Ignition"
+	// BootstrapFormatCloudConfig renders the userData together with the host configuration into a cloud-init cloud-config.
+	BootstrapFormatCloudConfig BootstrapFormat = "CloudConfig"
+	// BootstrapFormatRaw hands over the userData as-is without any host configuration.
+	BootstrapFormatRaw BootstrapFormat = "Raw"
+)
+
 // RootDisk defines the root disk properties of the Machine.
 type RootDisk struct {
 	// Size defines the volume size of the root disk.
@@ -48,4 +265,130 @@ type RootDisk struct {
 	VolumeClassName string `json:"volumeClassName"`
 	// VolumePoolName defines on which VolumePool a Volume should be scheduled.
 	VolumePoolName string `json:"volumePoolName,omitempty"`
+	// VolumeSnapshotName is the name of a VolumeSnapshot the root disk is restored from instead of the Image.
+	// It is mutually exclusive with Image.
+	VolumeSnapshotName string `json:"volumeSnapshotName,omitempty"`
+	// Persistent defines whether the root disk is a named Volume which is not bound to the lifecycle of the Machine.
+	// The Volume is named after the machine and reused if the machine is created again.
+	Persistent bool `json:"persistent,omitempty"`
+	// ReclaimPolicy defines what happens to a persistent root disk when the machine is deleted.
+	// Defaults to ReclaimPolicyDelete. It is only supported for persistent root disks.
+	ReclaimPolicy ReclaimPolicy `json:"reclaimPolicy,omitempty"`
+	// EncryptionSecretName is the name of a Secret in the ironcore namespace containing the encryption key of the Volume.
+	EncryptionSecretName string `json:"encryptionSecretName,omitempty"`
+	// Labels are additional labels of the Volume, e.g. to select a performance tier.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Tolerations define which VolumePool taints the Volume tolerates.
+	Tolerations []Toleration `json:"tolerations,omitempty"`
+}
+
+// ReclaimPolicy defines what happens to a persistent root disk when the machine is deleted.
+type ReclaimPolicy string
+
+const (
+	// ReclaimPolicyDelete deletes the root disk Volume after the Machine is gone.
+	ReclaimPolicyDelete ReclaimPolicy = "Delete"
+	// ReclaimPolicyRetain keeps the root disk Volume, so it is reused when the machine is created again.
+	ReclaimPolicyRetain ReclaimPolicy = "Retain"
+)
+
+// MaxDataDisks is the maximum number of data disks of a Machine, as data disks are attached as the devices "odb" to "odz".
+const MaxDataDisks = 25
+
+// DataDiskDevice returns the device name of the data disk at the given index of DataDisks. The root disk keeps the
+// first device name "oda".
+func DataDiskDevice(index int) string {
+	return fmt.Sprintf("od%c", 'b'+index)
+}
+
+// DataDisk defines an additional volume of the Machine.
+type DataDisk struct {
+	// Name is the name of the volume in the Machine. It must be unique and must not be RootDiskVolumeName.
+	Name string `json:"name"`
+	// Size defines the volume size of the data disk.
+	Size resource.Quantity `json:"size"`
+	// VolumeClassName defines which volume class to use for the data disk.
+	VolumeClassName string `json:"volumeClassName"`
+	// VolumePoolName defines on which VolumePool a Volume should be scheduled.
+	VolumePoolName string `json:"volumePoolName,omitempty"`
+	// EncryptionSecretName is the name of a Secret in the ironcore namespace containing the encryption key of the Volume.
+	EncryptionSecretName string `json:"encryptionSecretName,omitempty"`
+	// Labels are additional labels of the Volume, e.g. to select a performance tier.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Tolerations define which VolumePool taints the Volume tolerates.
+	Tolerations []Toleration `json:"tolerations,omitempty"`
+}
+
+// Toleration allows a Volume to be scheduled on a VolumePool with a matching taint.
+type Toleration struct {
+	// Key is the taint key the toleration applies to. An empty key with TolerationOpExists matches all keys.
+	Key string `json:"key,omitempty"`
+	// Operator is the relationship of the key to the value. Defaults to TolerationOpEqual.
+	Operator TolerationOperator `json:"operator,omitempty"`
+	// Value is the taint value the toleration matches to. It must be empty with TolerationOpExists.
+	Value string `json:"value,omitempty"`
+	// Effect is the taint effect to match. An empty effect matches all effects.
+	Effect TaintEffect `json:"effect,omitempty"`
+}
+
+// TolerationOperator is the relationship of a toleration key to its value.
+type TolerationOperator string
+
+const (
+	// TolerationOpEqual matches a taint with the same key and value.
+	TolerationOpEqual TolerationOperator = "Equal"
+	// TolerationOpExists matches a taint with the same key regardless of its value.
+	TolerationOpExists TolerationOperator = "Exists"
+)
+
+// TaintEffect is the effect of a VolumePool taint.
+type TaintEffect string
+
+const (
+	// TaintEffectNoSchedule prevents Volumes from being scheduled on the VolumePool unless they tolerate the taint.
+	TaintEffectNoSchedule TaintEffect = "NoSchedule"
+)
+
+// Filesystem defines a filesystem which should be created on a data disk of the Machine.
+type Filesystem struct {
+	// VolumeName is the name of the data disk the filesystem is created on. The block device of the data disk is
+	// derived from its position in DataDisks.
+	VolumeName string `json:"volumeName"`
+	// Format is the filesystem format, e.g. "ext4" or "xfs".
+	Format string `json:"format"`
+	// Path is the absolute path the filesystem is mounted at. If it is empty the filesystem is not mounted.
+	Path string `json:"path,omitempty"`
+	// Label is the label of the filesystem. It may only contain alphanumeric characters, '_', '.' and '-', its
+	// maximum length depends on the format, e.g. 16 characters for ext4 and 12 for xfs.
+	Label string `json:"label,omitempty"`
+	// WipeFilesystem defines whether an existing filesystem on the device should be wiped.
+	WipeFilesystem bool `json:"wipeFilesystem,omitempty"`
+	// MountOptions is a list of options which are used to mount the filesystem.
+	MountOptions []string `json:"mountOptions,omitempty"`
+}
+
+const (
+	// MaxMachineNameLength is the maximum length of the name of an ironcore Machine, as it is used as hostname and
+	// node name.
+	MaxMachineNameLength = 63
+	// MachineNameHashSuffixLength is the length of the suffix appended with MachineNaming.HashSuffix, a "-" followed by
+	// 10 characters of the hash.
+	MachineNameHashSuffixLength = 11
+)
+
+// MachineNaming defines how the name of the ironcore Machine is derived from the machine name, e.g. to avoid name
+// collisions if several machine controllers share an ironcore namespace. The name of the machine is recorded on the
+// ironcore Machine, so that the machine can be recovered from it. The name must not be longer than
+// MaxMachineNameLength characters.
+type MachineNaming struct {
+	// Prefix is prepended to the name.
+	Prefix string `json:"prefix,omitempty"`
+	// Template is a Go template of the name. It is executed with the fields Name and Namespace of the machine and
+	// ShootName and ShootNamespace of the "shoot-name" and "shoot-namespace" labels, e.g.
+	// "{{ .ShootName }}-{{ .Name }}". Defaults to "{{ .Name }}". It has to reference the Name unless HashSuffix is
+	// set, so that the machines of a class get distinct names.
+	Template string `json:"template,omitempty"`
+	// HashSuffix appends a hash of the namespace and the name of the machine to the name. The name is truncated to
+	// make room for the hash, so that it does not exceed MaxMachineNameLength characters.
+	HashSuffix bool `json:"hashSuffix,omitempty"`
 }
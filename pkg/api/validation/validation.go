@@ -4,10 +4,29 @@
 package validation
 
 import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
 	"net/netip"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"golang.org/x/crypto/ssh"
 
 	corev1 "k8s.io/api/core/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
 )
@@ -34,17 +53,118 @@ func validateSecret(secret *corev1.Secret, fldPath *field.Path) field.ErrorList
 		allErrs = append(allErrs, field.Required(field.NewPath("userData"), "userData is required"))
 	}
 
+	allErrs = append(allErrs, validateSecretCredentials(secret)...)
+
 	return allErrs
 }
 
-func validateIroncoreMachineClassSpec(spec *v1alpha1.ProviderSpec, fldPath *field.Path) field.ErrorList {
+// validateSecretCredentials validates the optional ironcore credentials of the secret, which are either a kubeconfig
+// or a token together with the server and its CA bundle.
+func validateSecretCredentials(secret *corev1.Secret) field.ErrorList {
 	var allErrs field.ErrorList
 
-	if spec.RootDisk != nil && spec.RootDisk.VolumeClassName == "" {
-		allErrs = append(allErrs, field.Required(fldPath.Child("rootDisk").Child("volumeClassName"), "volumeClassName is required"))
+	kubeconfig := secret.Data[v1alpha1.SecretKubeconfigKey]
+	token := secret.Data[v1alpha1.SecretTokenKey]
+	server := string(secret.Data[v1alpha1.SecretServerKey])
+	caBundle := secret.Data[v1alpha1.SecretCAKey]
+	namespace := string(secret.Data[v1alpha1.SecretNamespaceKey])
+
+	if len(kubeconfig) > 0 {
+		if config, err := clientcmd.Load(kubeconfig); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath(v1alpha1.SecretKubeconfigKey), "", fmt.Sprintf("kubeconfig is invalid: %v", err)))
+		} else {
+			allErrs = append(allErrs, ValidateKubeconfig(config, field.NewPath(v1alpha1.SecretKubeconfigKey))...)
+		}
+		if len(token) > 0 {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath(v1alpha1.SecretTokenKey), "token must not be set together with kubeconfig"))
+		}
 	}
 
-	if spec.Image == "" {
+	if len(token) > 0 {
+		if server == "" {
+			allErrs = append(allErrs, field.Required(field.NewPath(v1alpha1.SecretServerKey), "server is required with token"))
+		}
+		if namespace == "" {
+			allErrs = append(allErrs, field.Required(field.NewPath(v1alpha1.SecretNamespaceKey), "namespace is required with token"))
+		}
+	} else {
+		if server != "" {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath(v1alpha1.SecretServerKey), "server is only supported with token"))
+		}
+		if len(caBundle) > 0 {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath(v1alpha1.SecretCAKey), "ca.crt is only supported with token"))
+		}
+	}
+
+	if server != "" {
+		if u, err := url.Parse(server); err != nil || u.Scheme != "https" || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(field.NewPath(v1alpha1.SecretServerKey), server, "server must be an https URL"))
+		}
+	}
+
+	if len(caBundle) > 0 {
+		allErrs = append(allErrs, validateCACertificate(string(caBundle), field.NewPath(v1alpha1.SecretCAKey))...)
+	}
+
+	if namespace != "" {
+		for _, msg := range validation.IsDNS1123Label(namespace) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath(v1alpha1.SecretNamespaceKey), namespace, msg))
+		}
+	}
+
+	return allErrs
+}
+
+// ValidateKubeconfig validates that the given kubeconfig of a machine class secret only contains inline credentials.
+// Credentials which are read from files or obtained by running commands are forbidden, as they would be evaluated on
+// the host of the machine controller.
+func ValidateKubeconfig(kubeconfig *clientcmdapi.Config, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for _, name := range sets.List(sets.KeySet(kubeconfig.Clusters)) {
+		cluster := kubeconfig.Clusters[name]
+		if cluster.CertificateAuthority != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("clusters").Key(name).Child("certificate-authority"), "certificate-authority must be given inline as certificate-authority-data"))
+		}
+	}
+
+	for _, name := range sets.List(sets.KeySet(kubeconfig.AuthInfos)) {
+		authInfo := kubeconfig.AuthInfos[name]
+		userPath := fldPath.Child("users").Key(name)
+		if authInfo.ClientCertificate != "" {
+			allErrs = append(allErrs, field.Forbidden(userPath.Child("client-certificate"), "client-certificate must be given inline as client-certificate-data"))
+		}
+		if authInfo.ClientKey != "" {
+			allErrs = append(allErrs, field.Forbidden(userPath.Child("client-key"), "client-key must be given inline as client-key-data"))
+		}
+		if authInfo.TokenFile != "" {
+			allErrs = append(allErrs, field.Forbidden(userPath.Child("tokenFile"), "tokenFile must be given inline as token"))
+		}
+		if authInfo.Exec != nil {
+			allErrs = append(allErrs, field.Forbidden(userPath.Child("exec"), "exec is not supported"))
+		}
+		if authInfo.AuthProvider != nil {
+			allErrs = append(allErrs, field.Forbidden(userPath.Child("auth-provider"), "auth-provider is not supported"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateIroncoreMachineClassSpec(spec *v1alpha1.ProviderSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	allErrs = append(allErrs, validateRootDisk(spec.RootDisk, fldPath.Child("rootDisk"))...)
+
+	allErrs = append(allErrs, validateDataDisks(spec.DataDisks, fldPath.Child("dataDisks"))...)
+	allErrs = append(allErrs, validateFilesystems(spec.Filesystems, spec.DataDisks, fldPath.Child("filesystems"))...)
+	allErrs = append(allErrs, validateKernelArguments(spec.KernelArguments, fldPath.Child("kernelArguments"))...)
+
+	if spec.RootDisk != nil && spec.RootDisk.VolumeSnapshotName != "" {
+		if spec.Image != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("image"), "image must not be set if the root disk is restored from rootDisk.volumeSnapshotName"))
+		}
+	} else if spec.Image == "" {
 		allErrs = append(allErrs, field.Required(fldPath.Child("image"), "image is required"))
 	}
 
@@ -56,11 +176,809 @@ func validateIroncoreMachineClassSpec(spec *v1alpha1.ProviderSpec, fldPath *fiel
 		allErrs = append(allErrs, field.Required(fldPath.Child("prefixName"), "prefixName is required"))
 	}
 
+	allErrs = append(allErrs, metav1validation.ValidateLabels(spec.Labels, fldPath.Child("labels"))...)
+	allErrs = append(allErrs, apivalidation.ValidateAnnotations(spec.Annotations, fldPath.Child("annotations"))...)
+	allErrs = append(allErrs, validateMachineNaming(spec.Naming, fldPath.Child("naming"))...)
+
 	for i, ip := range spec.DnsServers {
 		if !netip.Addr.IsValid(ip) {
 			allErrs = append(allErrs, field.Invalid(fldPath.Child("dnsServers").Index(i), ip, "ip is invalid"))
 		}
 	}
 
+	for i, key := range spec.SSHAuthorizedKeys {
+		allErrs = append(allErrs, validateSSHAuthorizedKey(key, fldPath.Child("sshAuthorizedKeys").Index(i))...)
+	}
+
+	allErrs = append(allErrs, validateUsers(spec.Users, fldPath.Child("users"))...)
+
+	for i, cert := range spec.CACertificates {
+		allErrs = append(allErrs, validateCACertificate(cert, fldPath.Child("caCertificates").Index(i))...)
+	}
+
+	allErrs = append(allErrs, validateDNS(spec.DNS, fldPath.Child("dns"))...)
+	allErrs = append(allErrs, validateNetworkInterfaces(spec.NetworkInterfaces, fldPath.Child("networkInterfaces"))...)
+	allErrs = append(allErrs, validateHostInterfaceNameConflicts(getHostInterfaceNames(spec, fldPath))...)
+	allErrs = append(allErrs, validateNTP(spec.NTP, fldPath.Child("ntp"))...)
+	allErrs = append(allErrs, validateProxy(spec.Proxy, fldPath.Child("proxy"))...)
+	allErrs = append(allErrs, validateSysctls(spec.Sysctls, fldPath.Child("sysctls"))...)
+
+	allErrs = append(allErrs, validateBootstrap(spec.Bootstrap, fldPath.Child("bootstrap"))...)
+	allErrs = append(allErrs, validateBootstrapFormat(spec, fldPath)...)
+
+	return allErrs
+}
+
+func validateMachineNaming(naming *v1alpha1.MachineNaming, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if naming == nil {
+		return allErrs
+	}
+
+	if naming.Prefix != "" {
+		for _, msg := range apivalidation.NameIsDNSSubdomain(naming.Prefix, true) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("prefix"), naming.Prefix, msg))
+		}
+
+		// at least one character of the name has to follow the prefix
+		maxPrefixLength := v1alpha1.MaxMachineNameLength - 1
+		if naming.HashSuffix {
+			maxPrefixLength -= v1alpha1.MachineNameHashSuffixLength
+		}
+		if len(naming.Prefix) > maxPrefixLength {
+			allErrs = append(allErrs, field.TooLong(fldPath.Child("prefix"), naming.Prefix, maxPrefixLength))
+		}
+	}
+
+	if naming.Template != "" {
+		tmpl, err := template.New("name").Parse(naming.Template)
+		switch {
+		case err != nil:
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("template"), naming.Template, fmt.Sprintf("template is invalid: %v", err)))
+		case !naming.HashSuffix && !referencesField(tmpl.Tree.Root, "Name"):
+			// without the name or the hash of the machine all machines of the class would get the same name
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("template"), naming.Template, "template must reference .Name unless hashSuffix is set"))
+		}
+	}
+
+	return allErrs
+}
+
+// referencesField returns whether the given template node references the field of the given name.
+func referencesField(node parse.Node, name string) bool {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return false
+		}
+		for _, n := range node.Nodes {
+			if referencesField(n, name) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return referencesField(node.Pipe, name)
+	case *parse.PipeNode:
+		if node == nil {
+			return false
+		}
+		for _, cmd := range node.Cmds {
+			if referencesField(cmd, name) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			if referencesField(arg, name) {
+				return true
+			}
+		}
+	case *parse.FieldNode:
+		return len(node.Ident) > 0 && node.Ident[0] == name
+	case *parse.IfNode:
+		return referencesField(&node.BranchNode, name)
+	case *parse.RangeNode:
+		return referencesField(&node.BranchNode, name)
+	case *parse.WithNode:
+		return referencesField(&node.BranchNode, name)
+	case *parse.BranchNode:
+		return referencesField(node.Pipe, name) || referencesField(node.List, name) || referencesField(node.ElseList, name)
+	case *parse.TemplateNode:
+		return referencesField(node.Pipe, name)
+	}
+	return false
+}
+
+var supportedReclaimPolicies = sets.New(
+	v1alpha1.ReclaimPolicyDelete,
+	v1alpha1.ReclaimPolicyRetain,
+)
+
+func validateRootDisk(rootDisk *v1alpha1.RootDisk, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if rootDisk == nil {
+		return allErrs
+	}
+
+	if rootDisk.VolumeClassName == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("volumeClassName"), "volumeClassName is required"))
+	}
+
+	if rootDisk.VolumeSnapshotName != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(rootDisk.VolumeSnapshotName) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("volumeSnapshotName"), rootDisk.VolumeSnapshotName, msg))
+		}
+	}
+
+	if rootDisk.ReclaimPolicy != "" {
+		if !rootDisk.Persistent {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("reclaimPolicy"), "reclaimPolicy is only supported for persistent root disks"))
+		} else if !supportedReclaimPolicies.Has(rootDisk.ReclaimPolicy) {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("reclaimPolicy"), rootDisk.ReclaimPolicy, sets.List(supportedReclaimPolicies)))
+		}
+	}
+
+	allErrs = append(allErrs, validateVolumeOptions(rootDisk.EncryptionSecretName, rootDisk.Labels, rootDisk.Tolerations, fldPath)...)
+
+	return allErrs
+}
+
+var (
+	supportedTolerationOperators = sets.New(
+		v1alpha1.TolerationOpEqual,
+		v1alpha1.TolerationOpExists,
+	)
+	supportedTaintEffects = sets.New(
+		v1alpha1.TaintEffectNoSchedule,
+	)
+)
+
+// validateVolumeOptions validates the options which are shared by the root disk and the data disks.
+func validateVolumeOptions(encryptionSecretName string, labels map[string]string, tolerations []v1alpha1.Toleration, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if encryptionSecretName != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(encryptionSecretName) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("encryptionSecretName"), encryptionSecretName, msg))
+		}
+	}
+
+	allErrs = append(allErrs, metav1validation.ValidateLabels(labels, fldPath.Child("labels"))...)
+
+	for i, toleration := range tolerations {
+		idxPath := fldPath.Child("tolerations").Index(i)
+
+		if toleration.Key != "" {
+			for _, msg := range validation.IsQualifiedName(toleration.Key) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("key"), toleration.Key, msg))
+			}
+		}
+
+		switch toleration.Operator {
+		case "", v1alpha1.TolerationOpEqual:
+			if toleration.Key == "" {
+				allErrs = append(allErrs, field.Required(idxPath.Child("key"), "key is required unless operator is Exists"))
+			}
+		case v1alpha1.TolerationOpExists:
+			if toleration.Value != "" {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("value"), toleration.Value, "value must be empty if operator is Exists"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("operator"), toleration.Operator, sets.List(supportedTolerationOperators)))
+		}
+
+		if toleration.Effect != "" && !supportedTaintEffects.Has(toleration.Effect) {
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("effect"), toleration.Effect, sets.List(supportedTaintEffects)))
+		}
+	}
+
+	return allErrs
+}
+
+var (
+	supportedDNSSECModes = sets.New(
+		v1alpha1.DNSSECModeEnabled,
+		v1alpha1.DNSSECModeDisabled,
+		v1alpha1.DNSSECModeAllowDowngrade,
+	)
+	supportedDNSOverTLSModes = sets.New(
+		v1alpha1.DNSOverTLSModeEnabled,
+		v1alpha1.DNSOverTLSModeDisabled,
+		v1alpha1.DNSOverTLSModeOpportunistic,
+	)
+)
+
+func validateDNS(dns *v1alpha1.DNS, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if dns == nil {
+		return allErrs
+	}
+
+	allErrs = append(allErrs, validateIPs(dns.Servers, fldPath.Child("servers"))...)
+	allErrs = append(allErrs, validateIPs(dns.FallbackServers, fldPath.Child("fallbackServers"))...)
+	allErrs = append(allErrs, validateSearchDomains(dns.SearchDomains, fldPath.Child("searchDomains"))...)
+
+	if dns.DNSSEC != "" && !supportedDNSSECModes.Has(dns.DNSSEC) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("dnssec"), dns.DNSSEC, sets.List(supportedDNSSECModes)))
+	}
+
+	if dns.DNSOverTLS != "" && !supportedDNSOverTLSModes.Has(dns.DNSOverTLS) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("dnsOverTLS"), dns.DNSOverTLS, sets.List(supportedDNSOverTLSModes)))
+	}
+
+	names := sets.New[string]()
+	for i, iface := range dns.Interfaces {
+		idxPath := fldPath.Child("interfaces").Index(i)
+
+		allErrs = append(allErrs, validateInterfaceName(iface.Name, idxPath.Child("name"))...)
+		if names.Has(iface.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), iface.Name))
+		}
+		names.Insert(iface.Name)
+
+		if len(iface.Servers) == 0 && len(iface.SearchDomains) == 0 {
+			allErrs = append(allErrs, field.Required(idxPath, "either servers or searchDomains is required"))
+		}
+
+		allErrs = append(allErrs, validateIPs(iface.Servers, idxPath.Child("servers"))...)
+		allErrs = append(allErrs, validateSearchDomains(iface.SearchDomains, idxPath.Child("searchDomains"))...)
+	}
+
+	return allErrs
+}
+
+func validateIPs(ips []netip.Addr, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, ip := range ips {
+		if !ip.IsValid() {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), ip, "ip is invalid"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateSearchDomains(domains []string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, domain := range domains {
+		// routing-only domains are prefixed with "~", "~." routes all queries which have no more specific domain
+		name := strings.TrimPrefix(domain, "~")
+		if name == "." && name != domain {
+			continue
+		}
+		for _, msg := range validation.IsDNS1123Subdomain(name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), domain, msg))
+		}
+	}
+
+	return allErrs
+}
+
+func validateInterfaceName(name string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if name == "" {
+		allErrs = append(allErrs, field.Required(fldPath, "name is required"))
+	} else if strings.ContainsAny(name, " \t\n/") {
+		allErrs = append(allErrs, field.Invalid(fldPath, name, "name must not contain whitespace or slashes"))
+	}
+
+	return allErrs
+}
+
+// unsafeFileNameChars matches the characters which are replaced when the file name of a systemd-networkd unit is
+// derived from a host interface name, see pkg/ignition.
+var unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// hostInterfaceName is a host interface name which is rendered into its own systemd-networkd unit.
+type hostInterfaceName struct {
+	name    string
+	fldPath *field.Path
+}
+
+// getHostInterfaceNames returns the host interface names of all network interfaces, VLANs and DNS interfaces of the
+// given ProviderSpec.
+func getHostInterfaceNames(spec *v1alpha1.ProviderSpec, fldPath *field.Path) []hostInterfaceName {
+	var names []hostInterfaceName
+
+	for i, networkInterface := range spec.NetworkInterfaces {
+		idxPath := fldPath.Child("networkInterfaces").Index(i)
+		names = append(names, hostInterfaceName{name: networkInterface.HostInterfaceName, fldPath: idxPath.Child("hostInterfaceName")})
+		for j, vlan := range networkInterface.VLANs {
+			names = append(names, hostInterfaceName{name: vlan.HostInterfaceName(), fldPath: idxPath.Child("vlans").Index(j).Child("name")})
+		}
+	}
+
+	if spec.DNS != nil {
+		for i, iface := range spec.DNS.Interfaces {
+			names = append(names, hostInterfaceName{name: iface.Name, fldPath: fldPath.Child("dns", "interfaces").Index(i).Child("name")})
+		}
+	}
+
+	return names
+}
+
+// validateHostInterfaceNameConflicts rejects distinct host interface names which would be rendered into the same unit
+// file or which match the same link, as systemd-networkd only applies the first matching unit to a link. Equal names
+// are merged into a single unit and therefore don't conflict.
+func validateHostInterfaceNameConflicts(names []hostInterfaceName) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, name := range names {
+		if name.name == "" {
+			continue
+		}
+		for _, other := range names[:i] {
+			if other.name == "" || other.name == name.name {
+				continue
+			}
+			switch {
+			case unsafeFileNameChars.ReplaceAllString(name.name, "_") == unsafeFileNameChars.ReplaceAllString(other.name, "_"):
+				allErrs = append(allErrs, field.Invalid(name.fldPath, name.name, fmt.Sprintf("name results in the same network unit file name as %s", other.fldPath)))
+			case hostInterfaceNamesOverlap(name.name, other.name):
+				allErrs = append(allErrs, field.Invalid(name.fldPath, name.name, fmt.Sprintf("name matches the same interfaces as %s", other.fldPath)))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// hostInterfaceNamesOverlap reports whether one of the given names is a glob which matches the other name.
+func hostInterfaceNamesOverlap(a, b string) bool {
+	if ok, _ := path.Match(a, b); ok {
+		return true
+	}
+	ok, _ := path.Match(b, a)
+	return ok
+}
+
+const (
+	minMTU = 68
+	maxMTU = 65535
+
+	minVLANID = 1
+	maxVLANID = 4094
+
+	// maxHostInterfaceNameLength is the maximum length of an interface name in the Linux kernel
+	maxHostInterfaceNameLength = 15
+)
+
+var supportedNetworkInterfaceNames = sets.New(v1alpha1.PrimaryNetworkInterfaceName)
+
+func validateNetworkInterfaces(networkInterfaces []v1alpha1.NetworkInterface, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	names := sets.New[string]()
+	vlanNames := sets.New[string]()
+	for i, networkInterface := range networkInterfaces {
+		idxPath := fldPath.Index(i)
+
+		switch {
+		case networkInterface.Name == "":
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), "name is required"))
+		case !supportedNetworkInterfaceNames.Has(networkInterface.Name):
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("name"), networkInterface.Name, sets.List(supportedNetworkInterfaceNames)))
+		case names.Has(networkInterface.Name):
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), networkInterface.Name))
+		}
+		names.Insert(networkInterface.Name)
+
+		allErrs = append(allErrs, validateInterfaceName(networkInterface.HostInterfaceName, idxPath.Child("hostInterfaceName"))...)
+		allErrs = append(allErrs, validateMTU(networkInterface.MTU, idxPath.Child("mtu"))...)
+		allErrs = append(allErrs, validateRoutes(networkInterface.Routes, idxPath.Child("routes"))...)
+
+		for j, vlan := range networkInterface.VLANs {
+			vlanPath := idxPath.Child("vlans").Index(j)
+
+			if vlan.ID < minVLANID || vlan.ID > maxVLANID {
+				allErrs = append(allErrs, field.Invalid(vlanPath.Child("id"), vlan.ID, fmt.Sprintf("id must be between %d and %d", minVLANID, maxVLANID)))
+			}
+
+			hostInterfaceName := vlan.HostInterfaceName()
+			switch {
+			case len(hostInterfaceName) > maxHostInterfaceNameLength:
+				allErrs = append(allErrs, field.TooLong(vlanPath.Child("name"), hostInterfaceName, maxHostInterfaceNameLength))
+			case strings.ContainsAny(hostInterfaceName, " \t\n/*?[]"):
+				allErrs = append(allErrs, field.Invalid(vlanPath.Child("name"), hostInterfaceName, "name must not contain whitespace, slashes or globs"))
+			case vlanNames.Has(hostInterfaceName):
+				allErrs = append(allErrs, field.Duplicate(vlanPath.Child("name"), hostInterfaceName))
+			}
+			vlanNames.Insert(hostInterfaceName)
+
+			allErrs = append(allErrs, validateMTU(vlan.MTU, vlanPath.Child("mtu"))...)
+			for k, address := range vlan.Addresses {
+				if !address.IsValid() {
+					allErrs = append(allErrs, field.Invalid(vlanPath.Child("addresses").Index(k), address, "address is invalid"))
+				}
+			}
+			allErrs = append(allErrs, validateRoutes(vlan.Routes, vlanPath.Child("routes"))...)
+		}
+	}
+
+	return allErrs
+}
+
+func validateMTU(mtu *int32, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if mtu != nil && (*mtu < minMTU || *mtu > maxMTU) {
+		allErrs = append(allErrs, field.Invalid(fldPath, *mtu, fmt.Sprintf("mtu must be between %d and %d", minMTU, maxMTU)))
+	}
+
+	return allErrs
+}
+
+func validateRoutes(routes []v1alpha1.Route, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, route := range routes {
+		idxPath := fldPath.Index(i)
+
+		if !route.Destination.IsValid() {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("destination"), route.Destination, "destination is invalid"))
+			continue
+		}
+
+		if route.Gateway == nil {
+			continue
+		}
+		switch {
+		case !route.Gateway.IsValid():
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("gateway"), *route.Gateway, "gateway is invalid"))
+		case route.Gateway.Is4() != route.Destination.Addr().Is4():
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("gateway"), *route.Gateway, "gateway must be of the same ip family as the destination"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateNTP(ntp *v1alpha1.NTP, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if ntp == nil {
+		return allErrs
+	}
+
+	if len(ntp.Servers) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("servers"), "at least one ntp server is required"))
+	}
+
+	for i, server := range ntp.Servers {
+		if _, err := netip.ParseAddr(server); err == nil {
+			continue
+		}
+		for _, msg := range validation.IsDNS1123Subdomain(server) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("servers").Index(i), server, msg))
+		}
+	}
+
+	return allErrs
+}
+
+func validateProxy(proxy *v1alpha1.Proxy, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if proxy == nil {
+		return allErrs
+	}
+
+	if proxy.HTTPProxy == "" && proxy.HTTPSProxy == "" {
+		allErrs = append(allErrs, field.Required(fldPath, "either httpProxy or httpsProxy is required"))
+	}
+
+	allErrs = append(allErrs, validateHTTPURL(proxy.HTTPProxy, fldPath.Child("httpProxy"))...)
+	allErrs = append(allErrs, validateHTTPURL(proxy.HTTPSProxy, fldPath.Child("httpsProxy"))...)
+
+	for i, host := range proxy.NoProxy {
+		if host == "" || strings.ContainsAny(host, ", \t\n\"") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("noProxy").Index(i), host, "must be a non-empty host, domain or CIDR without commas, quotes or whitespace"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateHTTPURL(rawURL string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if rawURL == "" {
+		return allErrs
+	}
+
+	u, err := url.Parse(rawURL)
+	switch {
+	case err != nil:
+		allErrs = append(allErrs, field.Invalid(fldPath, rawURL, fmt.Sprintf("url is invalid: %v", err)))
+	case u.Scheme != "http" && u.Scheme != "https":
+		allErrs = append(allErrs, field.Invalid(fldPath, rawURL, "url scheme must be http or https"))
+	case u.Host == "":
+		allErrs = append(allErrs, field.Invalid(fldPath, rawURL, "url host is required"))
+	case strings.ContainsAny(rawURL, " \t\n\"'"):
+		allErrs = append(allErrs, field.Invalid(fldPath, rawURL, "url must not contain quotes or whitespace"))
+	}
+
+	return allErrs
+}
+
+var sysctlNameRegexp = regexp.MustCompile(`^([a-z0-9]([-_a-z0-9]*[a-z0-9])?[./])*[a-z0-9]([-_a-z0-9]*[a-z0-9])?$`)
+
+func validateSysctls(sysctls map[string]string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for name, value := range sysctls {
+		if !sysctlNameRegexp.MatchString(name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(name), name, fmt.Sprintf("name must match %s", sysctlNameRegexp)))
+		}
+		if strings.TrimSpace(value) == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Key(name), "value must not be empty"))
+		} else if strings.ContainsAny(value, "\n\r") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(name), value, "value must not contain line breaks"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateBootstrap(bootstrap *v1alpha1.Bootstrap, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if bootstrap == nil {
+		return allErrs
+	}
+
+	if bootstrap.MaxRetries != nil && *bootstrap.MaxRetries < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxRetries"), *bootstrap.MaxRetries, "maxRetries must not be negative"))
+	}
+
+	if bootstrap.RetryInterval != nil && bootstrap.RetryInterval.Duration < time.Second {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("retryInterval"), bootstrap.RetryInterval.Duration.String(), "retryInterval must be at least 1s"))
+	}
+
+	if bootstrap.Timeout != nil && bootstrap.Timeout.Duration < time.Second {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeout"), bootstrap.Timeout.Duration.String(), "timeout must be at least 1s"))
+	}
+
+	allErrs = append(allErrs, validateHTTPURL(bootstrap.CallbackURL, fldPath.Child("callbackURL"))...)
+
+	return allErrs
+}
+
+var supportedBootstrapFormats = sets.New(
+	v1alpha1.BootstrapFormatIgnition,
+	v1alpha1.BootstrapFormatCloudConfig,
+	v1alpha1.BootstrapFormatRaw,
+)
+
+func validateBootstrapFormat(spec *v1alpha1.ProviderSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if spec.BootstrapFormat == "" || spec.BootstrapFormat == v1alpha1.BootstrapFormatIgnition {
+		return allErrs
+	}
+
+	if !supportedBootstrapFormats.Has(spec.BootstrapFormat) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("bootstrapFormat"), spec.BootstrapFormat, sets.List(supportedBootstrapFormats)))
+		return allErrs
+	}
+
+	if spec.BootstrapFormat == v1alpha1.BootstrapFormatRaw && spec.Bootstrap != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("bootstrap"), "bootstrap is not supported with bootstrapFormat Raw"))
+	}
+
+	if spec.Ignition != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("ignition"), "ignition is only supported with bootstrapFormat Ignition"))
+	}
+
+	if spec.IgnitionOverride {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("ignitionOverride"), "ignitionOverride is only supported with bootstrapFormat Ignition"))
+	}
+
+	if len(spec.Filesystems) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("filesystems"), "filesystems are only supported with bootstrapFormat Ignition"))
+	}
+
+	if len(spec.KernelArguments) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("kernelArguments"), "kernelArguments are only supported with bootstrapFormat Ignition"))
+	}
+
+	return allErrs
+}
+
+func validateDataDisks(dataDisks []v1alpha1.DataDisk, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if len(dataDisks) > v1alpha1.MaxDataDisks {
+		allErrs = append(allErrs, field.TooMany(fldPath, len(dataDisks), v1alpha1.MaxDataDisks))
+	}
+
+	names := sets.New(v1alpha1.RootDiskVolumeName)
+	for i, dataDisk := range dataDisks {
+		idxPath := fldPath.Index(i)
+
+		if dataDisk.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), "name is required"))
+		} else {
+			for _, msg := range validation.IsDNS1123Label(dataDisk.Name) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), dataDisk.Name, msg))
+			}
+			if names.Has(dataDisk.Name) {
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), dataDisk.Name))
+			}
+		}
+		names.Insert(dataDisk.Name)
+
+		if dataDisk.VolumeClassName == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("volumeClassName"), "volumeClassName is required"))
+		}
+
+		if dataDisk.Size.Sign() <= 0 {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("size"), dataDisk.Size.String(), "size must be greater than zero"))
+		}
+
+		allErrs = append(allErrs, validateVolumeOptions(dataDisk.EncryptionSecretName, dataDisk.Labels, dataDisk.Tolerations, idxPath)...)
+	}
+
+	return allErrs
+}
+
+var (
+	supportedFilesystemFormats = sets.New("ext4", "xfs", "btrfs", "vfat", "swap")
+	// maxFilesystemLabelLengths are the maximum label lengths of the supported filesystem formats.
+	maxFilesystemLabelLengths = map[string]int{
+		"ext4":  16,
+		"xfs":   12,
+		"btrfs": 255,
+		"vfat":  11,
+		"swap":  16,
+	}
+)
+
+func validateFilesystems(filesystems []v1alpha1.Filesystem, dataDisks []v1alpha1.DataDisk, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	dataDiskNames := sets.New[string]()
+	for _, dataDisk := range dataDisks {
+		dataDiskNames.Insert(dataDisk.Name)
+	}
+
+	volumeNames := sets.New[string]()
+	paths := sets.New[string]()
+	for i, filesystem := range filesystems {
+		idxPath := fldPath.Index(i)
+
+		switch {
+		case filesystem.VolumeName == "":
+			allErrs = append(allErrs, field.Required(idxPath.Child("volumeName"), "volumeName is required"))
+		case !dataDiskNames.Has(filesystem.VolumeName):
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("volumeName"), filesystem.VolumeName, "volumeName must reference a data disk"))
+		case volumeNames.Has(filesystem.VolumeName):
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("volumeName"), filesystem.VolumeName))
+		}
+		volumeNames.Insert(filesystem.VolumeName)
+
+		if !supportedFilesystemFormats.Has(filesystem.Format) {
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("format"), filesystem.Format, sets.List(supportedFilesystemFormats)))
+		}
+
+		if filesystem.Path != "" {
+			switch {
+			case filesystem.Format == "swap":
+				allErrs = append(allErrs, field.Forbidden(idxPath.Child("path"), "path must not be set for swap filesystems"))
+			case !path.IsAbs(filesystem.Path) || path.Clean(filesystem.Path) == "/":
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("path"), filesystem.Path, "path must be an absolute path other than /"))
+			case paths.Has(path.Clean(filesystem.Path)):
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("path"), filesystem.Path))
+			}
+			paths.Insert(path.Clean(filesystem.Path))
+		}
+
+		if filesystem.Label != "" {
+			switch maxLength, ok := maxFilesystemLabelLengths[filesystem.Format]; {
+			case unsafeFileNameChars.MatchString(filesystem.Label):
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("label"), filesystem.Label, "label must only contain alphanumeric characters, '_', '.' and '-'"))
+			case ok && len(filesystem.Label) > maxLength:
+				allErrs = append(allErrs, field.TooLong(idxPath.Child("label"), filesystem.Label, maxLength))
+			}
+		}
+
+		for j, mountOption := range filesystem.MountOptions {
+			if mountOption == "" || strings.ContainsAny(mountOption, ", \t\r\n") {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("mountOptions").Index(j), mountOption, "mount option must be non-empty and must not contain commas or whitespace"))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func validateKernelArguments(kernelArguments []string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, kernelArgument := range kernelArguments {
+		if kernelArgument == "" || strings.ContainsAny(kernelArgument, " \t\n") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), kernelArgument, "kernel argument must be non-empty and must not contain whitespace"))
+		}
+	}
+
+	return allErrs
+}
+
+var userNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+func validateUsers(users []v1alpha1.User, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	names := sets.New[string]()
+	for i, user := range users {
+		idxPath := fldPath.Index(i)
+
+		switch {
+		case user.Name == "":
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), "name is required"))
+		case !userNameRegexp.MatchString(user.Name):
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), user.Name, fmt.Sprintf("name must match %s", userNameRegexp)))
+		case names.Has(user.Name):
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), user.Name))
+		}
+		names.Insert(user.Name)
+
+		for j, group := range user.Groups {
+			if !userNameRegexp.MatchString(group) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("groups").Index(j), group, fmt.Sprintf("group must match %s", userNameRegexp)))
+			}
+		}
+
+		for j, key := range user.SSHAuthorizedKeys {
+			allErrs = append(allErrs, validateSSHAuthorizedKey(key, idxPath.Child("sshAuthorizedKeys").Index(j))...)
+		}
+
+		if user.Shell != "" && (!path.IsAbs(user.Shell) || strings.ContainsAny(user.Shell, " \t\r\n")) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("shell"), user.Shell, "shell must be an absolute path without whitespace"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateSSHAuthorizedKey(key string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if _, _, _, rest, err := ssh.ParseAuthorizedKey([]byte(key)); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath, key, fmt.Sprintf("ssh authorized key is invalid: %v", err)))
+	} else if len(bytes.TrimSpace(rest)) > 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath, key, "only a single ssh authorized key is allowed"))
+	}
+
+	return allErrs
+}
+
+func validateCACertificate(cert string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	rest := []byte(cert)
+	for len(bytes.TrimSpace(rest)) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil || block.Type != "CERTIFICATE" {
+			allErrs = append(allErrs, field.Invalid(fldPath, cert, "must only contain PEM encoded certificates"))
+			return allErrs
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath, cert, fmt.Sprintf("certificate is invalid: %v", err)))
+			return allErrs
+		}
+	}
+
+	if len(bytes.TrimSpace([]byte(cert))) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath, "certificate must not be empty"))
+	}
+
 	return allErrs
 }
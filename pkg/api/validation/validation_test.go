@@ -5,17 +5,37 @@ package validation
 
 import (
 	"net/netip"
+	"strings"
+	"time"
 
 	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/types"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
 )
 
 var fldPath *field.Path
 
+const (
+	sshAuthorizedKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIEg9vBVSM5IKAUjin2f6Hy2gtzLelUoSgwyMITQfcg6N test"
+	caCertificate    = `-----BEGIN CERTIFICATE-----
+MIIBezCCASGgAwIBAgIUVB4ysD93D5iiH7L7c2RnvnpTgMMwCgYIKoZIzj0EAwIw
+EjEQMA4GA1UEAwwHdGVzdC1jYTAgFw0yNjEwMTkwMjE1MDJaGA8yMTI2MDkyNTAy
+MTUwMlowEjEQMA4GA1UEAwwHdGVzdC1jYTBZMBMGByqGSM49AgEGCCqGSM49AwEH
+A0IABJd9VT1hBsBUHaaShoaXX+uIVywqkcFVMWHrY9elw/r0t5UOuWtCVQwW1fbh
+caXVyU1/cFiObIhtI1KN8hvBdPGjUzBRMB0GA1UdDgQWBBSmCxW+jC7UipuRyOSc
+q6XgvOPWCDAfBgNVHSMEGDAWgBSmCxW+jC7UipuRyOScq6XgvOPWCDAPBgNVHRMB
+Af8EBTADAQH/MAoGCCqGSM49BAMCA0gAMEUCID/316NUpuczo8YhfOuyPCnfAVjr
+mSSRY1MVdLWhS8u+AiEA1R5gZW8u4K5FS9mwJb1YS9bZr1MUKjRxxeoqVLJ66n4=
+-----END CERTIFICATE-----
+`
+)
+
 var _ = Describe("Machine", func() {
 	invalidIP := netip.Addr{}
 
@@ -44,6 +64,115 @@ var _ = Describe("Machine", func() {
 			fldPath,
 			ContainElement(field.Required(fldPath.Child("userData"), "userData is required")),
 		),
+		Entry("kubeconfig and token in secret",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+			},
+			&corev1.Secret{
+				Data: map[string][]byte{
+					"kubeconfig": []byte("foo: ["),
+					"token":      []byte("foo"),
+				},
+			},
+			fldPath,
+			SatisfyAll(
+				ContainElement(HaveField("Field", "kubeconfig")),
+				ContainElement(field.Forbidden(field.NewPath("token"), "token must not be set together with kubeconfig")),
+			),
+		),
+		Entry("kubeconfig with credentials outside of the kubeconfig in secret",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+			},
+			&corev1.Secret{
+				Data: map[string][]byte{
+					"kubeconfig": []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: ironcore
+  cluster:
+    server: https://ironcore.example.com
+    certificate-authority: /etc/ssl/ca.crt
+users:
+- name: ironcore
+  user:
+    client-certificate: /etc/ssl/tls.crt
+    client-key: /etc/ssl/tls.key
+    tokenFile: /var/run/token
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: /bin/sh
+    auth-provider:
+      name: oidc
+contexts:
+- name: ironcore
+  context:
+    cluster: ironcore
+    user: ironcore
+current-context: ironcore
+`),
+				},
+			},
+			fldPath,
+			SatisfyAll(
+				ContainElement(field.Forbidden(field.NewPath("kubeconfig", "clusters").Key("ironcore").Child("certificate-authority"), "certificate-authority must be given inline as certificate-authority-data")),
+				ContainElement(field.Forbidden(field.NewPath("kubeconfig", "users").Key("ironcore").Child("client-certificate"), "client-certificate must be given inline as client-certificate-data")),
+				ContainElement(field.Forbidden(field.NewPath("kubeconfig", "users").Key("ironcore").Child("client-key"), "client-key must be given inline as client-key-data")),
+				ContainElement(field.Forbidden(field.NewPath("kubeconfig", "users").Key("ironcore").Child("tokenFile"), "tokenFile must be given inline as token")),
+				ContainElement(field.Forbidden(field.NewPath("kubeconfig", "users").Key("ironcore").Child("exec"), "exec is not supported")),
+				ContainElement(field.Forbidden(field.NewPath("kubeconfig", "users").Key("ironcore").Child("auth-provider"), "auth-provider is not supported")),
+			),
+		),
+		Entry("token without server and namespace in secret",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+			},
+			&corev1.Secret{
+				Data: map[string][]byte{
+					"token": []byte("foo"),
+				},
+			},
+			fldPath,
+			SatisfyAll(
+				ContainElement(field.Required(field.NewPath("server"), "server is required with token")),
+				ContainElement(field.Required(field.NewPath("namespace"), "namespace is required with token")),
+			),
+		),
+		Entry("invalid server, ca and namespace in secret",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+			},
+			&corev1.Secret{
+				Data: map[string][]byte{
+					"token":     []byte("foo"),
+					"server":    []byte("http://ironcore.example.com"),
+					"ca.crt":    []byte("foo"),
+					"namespace": []byte("Foo"),
+				},
+			},
+			fldPath,
+			SatisfyAll(
+				ContainElement(field.Invalid(field.NewPath("server"), "http://ironcore.example.com", "server must be an https URL")),
+				ContainElement(field.Invalid(field.NewPath("ca.crt"), "foo", "must only contain PEM encoded certificates")),
+				ContainElement(HaveField("Field", "namespace")),
+			),
+		),
+		Entry("server and ca without token in secret",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+			},
+			&corev1.Secret{
+				Data: map[string][]byte{
+					"server": []byte("https://ironcore.example.com"),
+					"ca.crt": []byte(caCertificate),
+				},
+			},
+			fldPath,
+			SatisfyAll(
+				ContainElement(field.Forbidden(field.NewPath("server"), "server is only supported with token")),
+				ContainElement(field.Forbidden(field.NewPath("ca.crt"), "ca.crt is only supported with token")),
+			),
+		),
 		Entry("no image",
 			&v1alpha1.ProviderSpec{
 				Image:    "",
@@ -53,6 +182,179 @@ var _ = Describe("Machine", func() {
 			fldPath,
 			ContainElement(field.Required(fldPath.Child("spec.image"), "image is required")),
 		),
+		Entry("image with root disk volume snapshot",
+			&v1alpha1.ProviderSpec{
+				Image: "foo",
+				RootDisk: &v1alpha1.RootDisk{
+					VolumeSnapshotName: "golden",
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(field.Forbidden(fldPath.Child("spec.image"), "image must not be set if the root disk is restored from rootDisk.volumeSnapshotName")),
+		),
+		Entry("no image with root disk volume snapshot",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{
+					VolumeSnapshotName: "golden",
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			Not(ContainElement(field.Required(fldPath.Child("spec.image"), "image is required"))),
+		),
+		Entry("invalid root disk volume snapshot name",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{
+					VolumeSnapshotName: "Golden_Snapshot",
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(HaveField("Field", "spec.rootDisk.volumeSnapshotName")),
+		),
+		Entry("invalid root disk encryption secret name",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{
+					EncryptionSecretName: "Encryption_Key",
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(HaveField("Field", "spec.rootDisk.encryptionSecretName")),
+		),
+		Entry("invalid root disk labels",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{
+					Labels: map[string]string{"tier": "not valid"},
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(HaveField("Field", "spec.rootDisk.labels")),
+		),
+		Entry("invalid root disk tolerations",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{
+					Tolerations: []v1alpha1.Toleration{
+						{Value: "fast"},
+						{Key: "tier", Operator: v1alpha1.TolerationOpExists, Value: "fast"},
+						{Key: "tier", Operator: "foo"},
+						{Key: "tier", Effect: "NoExecute"},
+					},
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			SatisfyAll(
+				ContainElement(field.Required(fldPath.Child("spec.rootDisk.tolerations[0].key"), "key is required unless operator is Exists")),
+				ContainElement(field.Invalid(fldPath.Child("spec.rootDisk.tolerations[1].value"), "fast", "value must be empty if operator is Exists")),
+				ContainElement(field.NotSupported(fldPath.Child("spec.rootDisk.tolerations[2].operator"), v1alpha1.TolerationOperator("foo"), []v1alpha1.TolerationOperator{v1alpha1.TolerationOpEqual, v1alpha1.TolerationOpExists})),
+				ContainElement(field.NotSupported(fldPath.Child("spec.rootDisk.tolerations[3].effect"), v1alpha1.TaintEffect("NoExecute"), []v1alpha1.TaintEffect{v1alpha1.TaintEffectNoSchedule})),
+			),
+		),
+		Entry("invalid data disk encryption secret name",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				DataDisks: []v1alpha1.DataDisk{{
+					Name:                 "data",
+					EncryptionSecretName: "Encryption_Key",
+				}},
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(HaveField("Field", "spec.dataDisks[0].encryptionSecretName")),
+		),
+		Entry("invalid labels and annotations",
+			&v1alpha1.ProviderSpec{
+				RootDisk:    &v1alpha1.RootDisk{},
+				Labels:      map[string]string{"cost-center": "not valid"},
+				Annotations: map[string]string{"not valid": "foo"},
+			},
+			&corev1.Secret{},
+			fldPath,
+			SatisfyAll(
+				ContainElement(HaveField("Field", "spec.labels")),
+				ContainElement(HaveField("Field", "spec.annotations")),
+			),
+		),
+		Entry("invalid naming",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				Naming: &v1alpha1.MachineNaming{
+					Prefix:   "Foo_",
+					Template: "{{ .Name ",
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			SatisfyAll(
+				ContainElement(HaveField("Field", "spec.naming.prefix")),
+				ContainElement(SatisfyAll(
+					HaveField("Field", "spec.naming.template"),
+					HaveField("Detail", ContainSubstring("template is invalid")),
+				)),
+			),
+		),
+		Entry("too long naming prefix",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				Naming: &v1alpha1.MachineNaming{
+					Prefix:     strings.Repeat("a", 52),
+					HashSuffix: true,
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(field.TooLong(fldPath.Child("spec.naming.prefix"), strings.Repeat("a", 52), 51)),
+		),
+		Entry("naming template without the name",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				Naming: &v1alpha1.MachineNaming{
+					Template: "{{ .ShootName }}-{{ .Namespace }}",
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(field.Invalid(fldPath.Child("spec.naming.template"), "{{ .ShootName }}-{{ .Namespace }}", "template must reference .Name unless hashSuffix is set")),
+		),
+		Entry("naming template without the name but with hash suffix",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				Naming: &v1alpha1.MachineNaming{
+					Template:   "{{ .ShootName }}",
+					HashSuffix: true,
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			Not(ContainElement(HaveField("Field", HavePrefix("spec.naming")))),
+		),
+		Entry("naming template with the name in a pipeline",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				Naming: &v1alpha1.MachineNaming{
+					Template: `{{ if .ShootName }}{{ .ShootName }}-{{ end }}{{ printf "%s" .Name }}`,
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			Not(ContainElement(HaveField("Field", HavePrefix("spec.naming")))),
+		),
+		Entry("valid naming",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				Naming: &v1alpha1.MachineNaming{
+					Prefix:     "foo-",
+					Template:   "{{ .ShootName }}-{{ .Name }}",
+					HashSuffix: true,
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			Not(ContainElement(HaveField("Field", HavePrefix("spec.naming")))),
+		),
 		Entry("no volumeclass name",
 			&v1alpha1.ProviderSpec{
 				RootDisk: &v1alpha1.RootDisk{
@@ -63,6 +365,27 @@ var _ = Describe("Machine", func() {
 			fldPath,
 			ContainElement(field.Required(fldPath.Child("spec.rootDisk.volumeClassName"), "volumeClassName is required")),
 		),
+		Entry("reclaim policy on non persistent root disk",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{
+					ReclaimPolicy: v1alpha1.ReclaimPolicyRetain,
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(field.Forbidden(fldPath.Child("spec.rootDisk.reclaimPolicy"), "reclaimPolicy is only supported for persistent root disks")),
+		),
+		Entry("unsupported reclaim policy",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{
+					Persistent:    true,
+					ReclaimPolicy: "foo",
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(field.NotSupported(fldPath.Child("spec.rootDisk.reclaimPolicy"), v1alpha1.ReclaimPolicy("foo"), []v1alpha1.ReclaimPolicy{v1alpha1.ReclaimPolicyDelete, v1alpha1.ReclaimPolicyRetain})),
+		),
 		Entry("no network name",
 			&v1alpha1.ProviderSpec{
 				NetworkName: "",
@@ -90,5 +413,343 @@ var _ = Describe("Machine", func() {
 			fldPath,
 			ContainElement(field.Invalid(fldPath.Child("spec.dnsServers[0]"), invalidIP, "ip is invalid")),
 		),
+		Entry("invalid ssh authorized key",
+			&v1alpha1.ProviderSpec{
+				RootDisk:          &v1alpha1.RootDisk{},
+				SSHAuthorizedKeys: []string{"ssh-ed25519 foo"},
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(SatisfyAll(
+				HaveField("Type", field.ErrorTypeInvalid),
+				HaveField("Field", "spec.sshAuthorizedKeys[0]"),
+			)),
+		),
+		Entry("invalid and duplicate user names",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				Users: []v1alpha1.User{
+					{Name: "Foo"},
+					{Name: "bar"},
+					{Name: "bar", SSHAuthorizedKeys: []string{"foo"}},
+					{},
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			SatisfyAll(
+				ContainElement(field.Invalid(fldPath.Child("spec.users[0].name"), "Foo", "name must match ^[a-z_][a-z0-9_-]{0,31}$")),
+				ContainElement(field.Duplicate(fldPath.Child("spec.users[2].name"), "bar")),
+				ContainElement(SatisfyAll(
+					HaveField("Type", field.ErrorTypeInvalid),
+					HaveField("Field", "spec.users[2].sshAuthorizedKeys[0]"),
+				)),
+				ContainElement(field.Required(fldPath.Child("spec.users[3].name"), "name is required")),
+			),
+		),
+		Entry("invalid user groups and shell",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				Users: []v1alpha1.User{
+					{Name: "foo", Groups: []string{"wheel", "{{ .UserData }}"}, Shell: "bash"},
+					{Name: "bar", Shell: "/bin/bash -c id"},
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			SatisfyAll(
+				ContainElement(field.Invalid(fldPath.Child("spec.users[0].groups[1]"), "{{ .UserData }}", "group must match ^[a-z_][a-z0-9_-]{0,31}$")),
+				ContainElement(field.Invalid(fldPath.Child("spec.users[0].shell"), "bash", "shell must be an absolute path without whitespace")),
+				ContainElement(field.Invalid(fldPath.Child("spec.users[1].shell"), "/bin/bash -c id", "shell must be an absolute path without whitespace")),
+			),
+		),
+		Entry("invalid ca certificate",
+			&v1alpha1.ProviderSpec{
+				RootDisk:       &v1alpha1.RootDisk{},
+				CACertificates: []string{"foo"},
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(field.Invalid(fldPath.Child("spec.caCertificates[0]"), "foo", "must only contain PEM encoded certificates")),
+		),
+		Entry("valid ssh authorized key, user and ca certificate",
+			&v1alpha1.ProviderSpec{
+				RootDisk:          &v1alpha1.RootDisk{},
+				SSHAuthorizedKeys: []string{sshAuthorizedKey},
+				Users: []v1alpha1.User{
+					{Name: "foo", SSHAuthorizedKeys: []string{sshAuthorizedKey}},
+				},
+				CACertificates: []string{caCertificate},
+			},
+			&corev1.Secret{},
+			fldPath,
+			Not(ContainElement(HaveField("Field", Or(
+				HavePrefix("spec.sshAuthorizedKeys"),
+				HavePrefix("spec.users"),
+				HavePrefix("spec.caCertificates"),
+			)))),
+		),
+		Entry("unsupported bootstrap format",
+			&v1alpha1.ProviderSpec{
+				RootDisk:        &v1alpha1.RootDisk{},
+				BootstrapFormat: "foo",
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(field.NotSupported(fldPath.Child("spec.bootstrapFormat"), v1alpha1.BootstrapFormat("foo"), []v1alpha1.BootstrapFormat{
+				v1alpha1.BootstrapFormatCloudConfig,
+				v1alpha1.BootstrapFormatIgnition,
+				v1alpha1.BootstrapFormatRaw,
+			})),
+		),
+		Entry("ignition with cloud-config bootstrap format",
+			&v1alpha1.ProviderSpec{
+				RootDisk:         &v1alpha1.RootDisk{},
+				BootstrapFormat:  v1alpha1.BootstrapFormatCloudConfig,
+				Ignition:         "foo",
+				IgnitionOverride: true,
+			},
+			&corev1.Secret{},
+			fldPath,
+			SatisfyAll(
+				ContainElement(field.Forbidden(fldPath.Child("spec.ignition"), "ignition is only supported with bootstrapFormat Ignition")),
+				ContainElement(field.Forbidden(fldPath.Child("spec.ignitionOverride"), "ignitionOverride is only supported with bootstrapFormat Ignition")),
+			),
+		),
+		Entry("invalid ntp servers",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				NTP:      &v1alpha1.NTP{Servers: []string{"10.0.0.1", "ntp.example.com", "not a host"}},
+			},
+			&corev1.Secret{},
+			fldPath,
+			SatisfyAll(
+				ContainElement(HaveField("Field", "spec.ntp.servers[2]")),
+				Not(ContainElement(HaveField("Field", Or(Equal("spec.ntp.servers[0]"), Equal("spec.ntp.servers[1]"))))),
+			),
+		),
+		Entry("empty ntp servers",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				NTP:      &v1alpha1.NTP{},
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(field.Required(fldPath.Child("spec.ntp.servers"), "at least one ntp server is required")),
+		),
+		Entry("invalid proxy",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				Proxy: &v1alpha1.Proxy{
+					HTTPProxy:  "ftp://proxy.example.com",
+					HTTPSProxy: "http://",
+					NoProxy:    []string{"localhost", "foo,bar"},
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			SatisfyAll(
+				ContainElement(field.Invalid(fldPath.Child("spec.proxy.httpProxy"), "ftp://proxy.example.com", "url scheme must be http or https")),
+				ContainElement(field.Invalid(fldPath.Child("spec.proxy.httpsProxy"), "http://", "url host is required")),
+				ContainElement(field.Invalid(fldPath.Child("spec.proxy.noProxy[1]"), "foo,bar", "must be a non-empty host, domain or CIDR without commas, quotes or whitespace")),
+			),
+		),
+		Entry("empty proxy",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				Proxy:    &v1alpha1.Proxy{},
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(field.Required(fldPath.Child("spec.proxy"), "either httpProxy or httpsProxy is required")),
+		),
+		Entry("invalid sysctls",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				Sysctls: map[string]string{
+					"net.ipv4.ip_forward": "1",
+					"Net..foo":            "1",
+					"vm.swappiness":       "",
+					"kernel.foo":          "1\nkernel.bar = 2",
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			SatisfyAll(
+				ContainElement(HaveField("Field", "spec.sysctls[Net..foo]")),
+				ContainElement(field.Required(fldPath.Child("spec.sysctls").Key("vm.swappiness"), "value must not be empty")),
+				ContainElement(field.Invalid(fldPath.Child("spec.sysctls").Key("kernel.foo"), "1\nkernel.bar = 2", "value must not contain line breaks")),
+				Not(ContainElement(HaveField("Field", "spec.sysctls[net.ipv4.ip_forward]"))),
+			),
+		),
+		Entry("invalid dns configuration",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				DNS: &v1alpha1.DNS{
+					Servers:       []netip.Addr{{}},
+					SearchDomains: []string{"example.com", "~.", "~corp.example.com", "-invalid-"},
+					DNSSEC:        "foo",
+					DNSOverTLS:    "foo",
+					Interfaces: []v1alpha1.InterfaceDNS{
+						{Name: "eth0", Servers: []netip.Addr{netip.MustParseAddr("10.0.0.1")}},
+						{Name: "eth0", SearchDomains: []string{"example.com"}},
+						{Name: ""},
+						{Name: "eth*", SearchDomains: []string{"example.com"}},
+						{Name: "eth?", SearchDomains: []string{"example.com"}},
+					},
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			SatisfyAll(
+				ContainElement(field.Invalid(fldPath.Child("spec.dns.servers[0]"), netip.Addr{}, "ip is invalid")),
+				ContainElement(HaveField("Field", "spec.dns.searchDomains[3]")),
+				Not(ContainElement(HaveField("Field", Or(
+					Equal("spec.dns.searchDomains[0]"),
+					Equal("spec.dns.searchDomains[1]"),
+					Equal("spec.dns.searchDomains[2]"),
+				)))),
+				ContainElement(field.NotSupported(fldPath.Child("spec.dns.dnssec"), v1alpha1.DNSSECMode("foo"), []v1alpha1.DNSSECMode{
+					v1alpha1.DNSSECModeAllowDowngrade,
+					v1alpha1.DNSSECModeDisabled,
+					v1alpha1.DNSSECModeEnabled,
+				})),
+				ContainElement(HaveField("Field", "spec.dns.dnsOverTLS")),
+				ContainElement(field.Duplicate(fldPath.Child("spec.dns.interfaces[1].name"), "eth0")),
+				ContainElement(field.Required(fldPath.Child("spec.dns.interfaces[2].name"), "name is required")),
+				ContainElement(field.Required(fldPath.Child("spec.dns.interfaces[2]"), "either servers or searchDomains is required")),
+				ContainElement(field.Invalid(fldPath.Child("spec.dns.interfaces[3].name"), "eth*", "name matches the same interfaces as spec.dns.interfaces[0].name")),
+				ContainElement(field.Invalid(fldPath.Child("spec.dns.interfaces[4].name"), "eth?", "name results in the same network unit file name as spec.dns.interfaces[3].name")),
+			),
+		),
+		Entry("invalid network interfaces",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				NetworkInterfaces: []v1alpha1.NetworkInterface{
+					{
+						Name:              v1alpha1.PrimaryNetworkInterfaceName,
+						HostInterfaceName: "eth0",
+						MTU:               ptr.To[int32](10),
+						Routes: []v1alpha1.Route{
+							{Destination: netip.MustParsePrefix("10.0.0.0/8"), Gateway: ptr.To(netip.MustParseAddr("fe80::1"))},
+						},
+						VLANs: []v1alpha1.VLAN{
+							{ID: 100},
+							{ID: 5000, Name: "vlan100"},
+							{ID: 200, Name: "vlan_200"},
+						},
+					},
+					{Name: "foo"},
+				},
+				DNS: &v1alpha1.DNS{
+					Interfaces: []v1alpha1.InterfaceDNS{
+						{Name: "eth0", SearchDomains: []string{"example.com"}},
+						{Name: "eth*", SearchDomains: []string{"example.com"}},
+						{Name: "vlan:200", SearchDomains: []string{"example.com"}},
+					},
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			SatisfyAll(
+				ContainElement(field.Invalid(fldPath.Child("spec.networkInterfaces[0].mtu"), int32(10), "mtu must be between 68 and 65535")),
+				ContainElement(field.Invalid(fldPath.Child("spec.networkInterfaces[0].routes[0].gateway"), netip.MustParseAddr("fe80::1"), "gateway must be of the same ip family as the destination")),
+				ContainElement(field.Invalid(fldPath.Child("spec.networkInterfaces[0].vlans[1].id"), int32(5000), "id must be between 1 and 4094")),
+				ContainElement(field.Duplicate(fldPath.Child("spec.networkInterfaces[0].vlans[1].name"), "vlan100")),
+				ContainElement(field.NotSupported(fldPath.Child("spec.networkInterfaces[1].name"), "foo", []string{v1alpha1.PrimaryNetworkInterfaceName})),
+				ContainElement(field.Required(fldPath.Child("spec.networkInterfaces[1].hostInterfaceName"), "name is required")),
+				ContainElement(field.Invalid(fldPath.Child("spec.dns.interfaces[1].name"), "eth*", "name matches the same interfaces as spec.networkInterfaces[0].hostInterfaceName")),
+				ContainElement(field.Invalid(fldPath.Child("spec.dns.interfaces[2].name"), "vlan:200", "name results in the same network unit file name as spec.networkInterfaces[0].vlans[2].name")),
+				Not(ContainElement(HaveField("Field", "spec.dns.interfaces[0].name"))),
+			),
+		),
+		Entry("invalid data disks, filesystems and kernel arguments",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				DataDisks: []v1alpha1.DataDisk{
+					{Name: "root", Size: resource.MustParse("1Gi"), VolumeClassName: "foo"},
+					{Name: "data"},
+				},
+				Filesystems: []v1alpha1.Filesystem{
+					{VolumeName: "data", Format: "xfs", Path: "/var/lib/data"},
+					{VolumeName: "data", Format: "foo", Path: "var/lib/data"},
+					{VolumeName: "bar", Format: "swap", Path: "/swap"},
+					{VolumeName: "data", Format: "xfs", Label: "{{ .UserData }}", MountOptions: []string{"noatime", "", "ro,exec"}},
+					{VolumeName: "data", Format: "vfat", Label: "efi-partition"},
+				},
+				KernelArguments: []string{"hugepages=1024", "foo bar"},
+			},
+			&corev1.Secret{},
+			fldPath,
+			SatisfyAll(
+				ContainElement(field.Duplicate(fldPath.Child("spec.dataDisks[0].name"), "root")),
+				ContainElement(field.Required(fldPath.Child("spec.dataDisks[1].volumeClassName"), "volumeClassName is required")),
+				ContainElement(field.Invalid(fldPath.Child("spec.dataDisks[1].size"), "0", "size must be greater than zero")),
+				Not(ContainElement(HaveField("Field", HavePrefix("spec.filesystems[0]")))),
+				ContainElement(field.Duplicate(fldPath.Child("spec.filesystems[1].volumeName"), "data")),
+				ContainElement(HaveField("Field", "spec.filesystems[1].format")),
+				ContainElement(field.Invalid(fldPath.Child("spec.filesystems[1].path"), "var/lib/data", "path must be an absolute path other than /")),
+				ContainElement(field.Invalid(fldPath.Child("spec.filesystems[2].volumeName"), "bar", "volumeName must reference a data disk")),
+				ContainElement(field.Forbidden(fldPath.Child("spec.filesystems[2].path"), "path must not be set for swap filesystems")),
+				ContainElement(field.Invalid(fldPath.Child("spec.filesystems[3].label"), "{{ .UserData }}", "label must only contain alphanumeric characters, '_', '.' and '-'")),
+				ContainElement(field.Invalid(fldPath.Child("spec.filesystems[3].mountOptions[1]"), "", "mount option must be non-empty and must not contain commas or whitespace")),
+				ContainElement(field.Invalid(fldPath.Child("spec.filesystems[3].mountOptions[2]"), "ro,exec", "mount option must be non-empty and must not contain commas or whitespace")),
+				Not(ContainElement(HaveField("Field", "spec.filesystems[3].mountOptions[0]"))),
+				ContainElement(field.TooLong(fldPath.Child("spec.filesystems[4].label"), "efi-partition", 11)),
+				ContainElement(field.Invalid(fldPath.Child("spec.kernelArguments[1]"), "foo bar", "kernel argument must be non-empty and must not contain whitespace")),
+			),
+		),
+		Entry("too many data disks",
+			&v1alpha1.ProviderSpec{
+				RootDisk:  &v1alpha1.RootDisk{},
+				DataDisks: make([]v1alpha1.DataDisk, v1alpha1.MaxDataDisks+1),
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(field.TooMany(fldPath.Child("spec.dataDisks"), v1alpha1.MaxDataDisks+1, v1alpha1.MaxDataDisks)),
+		),
+		Entry("filesystems and kernel arguments with cloud-config bootstrap format",
+			&v1alpha1.ProviderSpec{
+				RootDisk:        &v1alpha1.RootDisk{},
+				BootstrapFormat: v1alpha1.BootstrapFormatCloudConfig,
+				Filesystems:     []v1alpha1.Filesystem{{VolumeName: "data"}},
+				KernelArguments: []string{"hugepages=1024"},
+			},
+			&corev1.Secret{},
+			fldPath,
+			SatisfyAll(
+				ContainElement(field.Forbidden(fldPath.Child("spec.filesystems"), "filesystems are only supported with bootstrapFormat Ignition")),
+				ContainElement(field.Forbidden(fldPath.Child("spec.kernelArguments"), "kernelArguments are only supported with bootstrapFormat Ignition")),
+			),
+		),
+		Entry("invalid bootstrap settings",
+			&v1alpha1.ProviderSpec{
+				RootDisk: &v1alpha1.RootDisk{},
+				Bootstrap: &v1alpha1.Bootstrap{
+					MaxRetries:    ptr.To[int32](-1),
+					RetryInterval: &metav1.Duration{Duration: time.Millisecond},
+					Timeout:       &metav1.Duration{},
+					CallbackURL:   "https://example.com/'status'",
+				},
+			},
+			&corev1.Secret{},
+			fldPath,
+			SatisfyAll(
+				ContainElement(field.Invalid(fldPath.Child("spec.bootstrap.maxRetries"), int32(-1), "maxRetries must not be negative")),
+				ContainElement(field.Invalid(fldPath.Child("spec.bootstrap.retryInterval"), "1ms", "retryInterval must be at least 1s")),
+				ContainElement(field.Invalid(fldPath.Child("spec.bootstrap.timeout"), "0s", "timeout must be at least 1s")),
+				ContainElement(field.Invalid(fldPath.Child("spec.bootstrap.callbackURL"), "https://example.com/'status'", "url must not contain quotes or whitespace")),
+			),
+		),
+		Entry("bootstrap settings with raw bootstrap format",
+			&v1alpha1.ProviderSpec{
+				RootDisk:        &v1alpha1.RootDisk{},
+				BootstrapFormat: v1alpha1.BootstrapFormatRaw,
+				Bootstrap:       &v1alpha1.Bootstrap{},
+			},
+			&corev1.Secret{},
+			fldPath,
+			ContainElement(field.Forbidden(fldPath.Child("spec.bootstrap"), "bootstrap is not supported with bootstrapFormat Raw")),
+		),
 	)
 })
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ignition
+
+import (
+	"bytes"
+	_ "embed"
+	"text/template"
+	"time"
+
+	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
+)
+
+const (
+	bootstrapScriptFile = "/var/lib/ironcore-cloud-config/run.sh"
+
+	defaultBootstrapRetryInterval = 5 * time.Second
+)
+
+var (
+	//go:embed bootstrap.sh
+	bootstrapScriptTemplate string
+
+	bootstrapScript = template.Must(template.New("bootstrap").Parse(bootstrapScriptTemplate))
+)
+
+// BootstrapScript renders the script which runs the bootstrap script from the UserData with retries and reports
+// its progress.
+func (c *Config) BootstrapScript() (string, error) {
+	settings := c.Bootstrap
+	if settings == nil {
+		settings = &v1alpha1.Bootstrap{}
+	}
+
+	data := struct {
+		MaxAttempts          int64
+		RetryIntervalSeconds int64
+		TimeoutSeconds       int64
+		CallbackURL          string
+	}{
+		RetryIntervalSeconds: int64(defaultBootstrapRetryInterval / time.Second),
+		CallbackURL:          settings.CallbackURL,
+	}
+	// without max retries the bootstrap script is retried until it succeeds, which is rendered as zero max attempts
+	if settings.MaxRetries != nil {
+		data.MaxAttempts = int64(*settings.MaxRetries) + 1
+	}
+	if settings.RetryInterval != nil {
+		data.RetryIntervalSeconds = int64(settings.RetryInterval.Duration / time.Second)
+	}
+	// a timeout of zero disables the timeout of a run
+	if settings.Timeout != nil {
+		data.TimeoutSeconds = int64(settings.Timeout.Duration / time.Second)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := bootstrapScript.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
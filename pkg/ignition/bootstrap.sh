@@ -0,0 +1,51 @@
+#!/bin/bash
+# Runs the bootstrap script of the machine with retries and reports its progress via the status file, the
+# systemd unit status and the optional callback endpoint.
+set -u
+
+state_dir="/var/lib/ironcore-cloud-config"
+max_attempts={{ .MaxAttempts }}
+retry_interval={{ .RetryIntervalSeconds }}
+attempt_timeout={{ .TimeoutSeconds }}
+callback_url='{{ .CallbackURL }}'
+
+report() {
+  local phase="$1" message="$2"
+
+  echo "${phase}: ${message}"
+  echo "${phase}" > "${state_dir}/init.status"
+  systemd-notify --status="${phase}: ${message}" >/dev/null 2>&1 || true
+
+  if [ -n "${callback_url}" ]; then
+    curl -fsS --max-time 10 -X POST -H "Content-Type: application/json" \
+      -d "{\"hostname\":\"$(hostname)\",\"phase\":\"${phase}\",\"message\":\"${message}\"}" \
+      "${callback_url}" >/dev/null || echo "failed to report bootstrap status to ${callback_url}"
+  fi
+}
+
+# a max_attempts of 0 retries the bootstrap script until it succeeds
+attempt=0
+while [ "${max_attempts}" -eq 0 ] || [ "${attempt}" -lt "${max_attempts}" ]; do
+  attempt=$((attempt + 1))
+  progress="attempt ${attempt}"
+  if [ "${max_attempts}" -gt 0 ]; then
+    progress="${progress} of ${max_attempts}"
+  fi
+  report Running "${progress}"
+
+  timeout "${attempt_timeout}" "${state_dir}/init.sh"
+  rc=$?
+  if [ "${rc}" -eq 0 ]; then
+    touch "${state_dir}/init.done"
+    report Succeeded "${progress}"
+    exit 0
+  fi
+
+  echo "bootstrap script failed with exit code ${rc}"
+  if [ "${max_attempts}" -eq 0 ] || [ "${attempt}" -lt "${max_attempts}" ]; then
+    sleep "${retry_interval}"
+  fi
+done
+
+report Failed "bootstrap script failed after ${max_attempts} attempts"
+exit 1
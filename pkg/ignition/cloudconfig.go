@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ignition
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/imdario/mergo"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	cloudConfigHeader = "#cloud-config"
+	initScriptFile    = "/var/lib/ironcore-cloud-config/init.sh"
+	initScriptMode    = 0755
+)
+
+// CloudConfig renders the Config into a cloud-init cloud-config. If the UserData is a cloud-config itself it is merged
+// with the host configuration, otherwise it is written to the host as script and executed on first boot by the
+// bootstrap script.
+func CloudConfig(config *Config) (string, error) {
+	var (
+		writeFiles    []interface{}
+		runCmd        []interface{}
+		applyCommands = sets.New[string]()
+	)
+
+	for _, f := range hostFiles(config) {
+		writeFiles = append(writeFiles, map[string]interface{}{
+			"path":        f.Path,
+			"permissions": fmt.Sprintf("%04o", f.Mode),
+			"content":     f.Contents,
+		})
+		if len(f.ApplyCommand) > 0 && !applyCommands.Has(strings.Join(f.ApplyCommand, " ")) {
+			applyCommands.Insert(strings.Join(f.ApplyCommand, " "))
+			runCmd = append(runCmd, f.ApplyCommand)
+		}
+	}
+
+	userCloudConfig := map[string]interface{}{}
+	if strings.HasPrefix(config.UserData, cloudConfigHeader) {
+		if err := yaml.Unmarshal([]byte(config.UserData), &userCloudConfig); err != nil {
+			return "", fmt.Errorf("failed to parse cloud-config from user data: %w", err)
+		}
+	} else {
+		bootstrapScript, err := config.BootstrapScript()
+		if err != nil {
+			return "", fmt.Errorf("failed to render bootstrap script: %w", err)
+		}
+		writeFiles = append(writeFiles, map[string]interface{}{
+			"path":        initScriptFile,
+			"permissions": fmt.Sprintf("%04o", initScriptMode),
+			"content":     config.UserData,
+		}, map[string]interface{}{
+			"path":        bootstrapScriptFile,
+			"permissions": fmt.Sprintf("%04o", initScriptMode),
+			"content":     bootstrapScript,
+		})
+		// the runcmd is executed by cloud-init which has been started before the proxy configuration was written
+		if env := proxyEnvironment(config.Proxy); len(env) > 0 {
+			runCmd = append(runCmd, append(append([]string{"env"}, env...), bootstrapScriptFile))
+		} else {
+			runCmd = append(runCmd, bootstrapScriptFile)
+		}
+	}
+
+	cloudConfig := map[string]interface{}{
+		"hostname": config.Hostname,
+	}
+	if len(config.SSHAuthorizedKeys) > 0 {
+		cloudConfig["ssh_authorized_keys"] = config.SSHAuthorizedKeys
+	}
+	if users := cloudConfigUsers(config); len(users) > 0 {
+		cloudConfig["users"] = users
+	}
+	if len(config.CACertificates) > 0 {
+		cloudConfig["ca_certs"] = map[string]interface{}{
+			"trusted": config.CACertificates,
+		}
+	}
+	if len(writeFiles) > 0 {
+		cloudConfig["write_files"] = writeFiles
+	}
+	if len(runCmd) > 0 {
+		cloudConfig["runcmd"] = runCmd
+	}
+
+	// convert the host configuration to generic types, so that its slices can be appended by the user data
+	data, err := yaml.Marshal(cloudConfig)
+	if err != nil {
+		return "", err
+	}
+	cloudConfig = map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &cloudConfig); err != nil {
+		return "", err
+	}
+
+	// merge the cloud-config from the user data, the host configuration takes precedence
+	if err := mergo.Merge(&cloudConfig, userCloudConfig, mergo.WithAppendSlice); err != nil {
+		return "", fmt.Errorf("failed to merge host configuration with cloud-config content: %w", err)
+	}
+
+	data, err = yaml.Marshal(cloudConfig)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s\n%s", cloudConfigHeader, data), nil
+}
@@ -8,13 +8,18 @@ import (
 	_ "embed"
 	"fmt"
 	"net/netip"
+	"sort"
 	"strings"
 	"text/template"
 
 	"github.com/Masterminds/sprig"
 	buconfig "github.com/coreos/butane/config"
 	"github.com/coreos/butane/config/common"
+	"github.com/coreos/go-semver/semver"
+	ignconfig "github.com/coreos/ignition/v2/config"
+	"github.com/coreos/vcontext/report"
 	"github.com/imdario/mergo"
+	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
 	"sigs.k8s.io/yaml"
 )
 
@@ -26,21 +31,53 @@ var (
 const (
 	dnsConfFile    = "/etc/systemd/resolved.conf.d/dns.conf"
 	dnsEqualString = "DNS="
+	ntpConfFile    = "/etc/systemd/timesyncd.conf.d/ntp.conf"
+	proxyConfFile  = "/etc/systemd/system.conf.d/proxy.conf"
+	sysctlConfFile = "/etc/sysctl.d/99-ironcore.conf"
 	fileMode       = 0644
+
+	// compressionThreshold is the size of the rendered ignition above which inline resources get compressed.
+	// Smaller ignitions are kept uncompressed to keep them readable.
+	compressionThreshold = 32 * 1024
+
+	// kernelArgumentsButaneVersion is the first fcos butane spec which supports kernel arguments.
+	kernelArgumentsButaneVersion = "1.4.0"
 )
 
+// Filesystem is a filesystem which is created on the block device at Device.
+type Filesystem struct {
+	v1alpha1.Filesystem
+	Device string
+}
+
 type Config struct {
 	Hostname         string
 	UserData         string
 	Ignition         string
 	IgnitionOverride bool
 	DnsServers       []netip.Addr
+	DNS              *v1alpha1.DNS
+	Strict           bool
+	Bootstrap        *v1alpha1.Bootstrap
+
+	SSHAuthorizedKeys []string
+	Users             []v1alpha1.User
+	CACertificates    []string
+
+	KernelArguments   []string
+	Filesystems       []Filesystem
+	NetworkInterfaces []v1alpha1.NetworkInterface
+	NTP               *v1alpha1.NTP
+	Proxy             *v1alpha1.Proxy
+	Sysctls           map[string]string
 }
 
-func File(config *Config) (string, error) {
+// File renders the Config into an Ignition config. Warnings reported while translating and validating the
+// ignition are returned alongside, in strict mode they cause an error instead.
+func File(config *Config) (string, []string, error) {
 	ignitionBase := &map[string]interface{}{}
 	if err := yaml.Unmarshal([]byte(IgnitionTemplate), ignitionBase); err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	// if ignition was set in providerSpec merge it with our template
@@ -48,7 +85,7 @@ func File(config *Config) (string, error) {
 		additional := map[string]interface{}{}
 
 		if err := yaml.Unmarshal([]byte(config.Ignition), &additional); err != nil {
-			return "", err
+			return "", nil, err
 		}
 
 		// default to append ignition
@@ -62,67 +99,305 @@ func File(config *Config) (string, error) {
 		// merge both ignitions
 		err := mergo.Merge(ignitionBase, additional, opt)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 	}
 
-	if len(config.DnsServers) > 0 {
-		dnsServers := []string{"[Resolve]"}
-		for _, v := range config.DnsServers {
-			dnsEntry := fmt.Sprintf("%s%s", dnsEqualString, v.String())
-			dnsServers = append(dnsServers, dnsEntry)
+	// only the template and the ignition of the provider spec are rendered as template, the host configuration is
+	// merged afterwards so that its values are written literally
+	baseIgnition, err := yaml.Marshal(ignitionBase)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tmpl, err := template.New("ignition").Funcs(sprig.HermeticTxtFuncMap()).Parse(string(baseIgnition))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed creating ignition file: %w", err)
+	}
+	buf := bytes.NewBufferString("")
+	err = tmpl.Execute(buf, config)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed creating ignition file while executing template: %w", err)
+	}
+
+	renderedIgnition := map[string]interface{}{}
+	if err := yaml.Unmarshal(buf.Bytes(), &renderedIgnition); err != nil {
+		return "", nil, fmt.Errorf("failed to parse rendered ignition: %w", err)
+	}
+
+	// merge host configuration with ignition content
+	if err := mergo.Merge(&renderedIgnition, hostConfig(config), mergo.WithAppendSlice); err != nil {
+		return "", nil, fmt.Errorf("failed to merge host configuration with igntition content: %w", err)
+	}
+
+	// kernel arguments require a newer butane spec, the version is only raised if they are used to keep the
+	// ignition compatible with hosts running older Ignition versions
+	if len(config.KernelArguments) > 0 {
+		if err := raiseButaneVersion(renderedIgnition, kernelArgumentsButaneVersion); err != nil {
+			return "", nil, err
 		}
+	}
+
+	mergedIgnition, err := yaml.Marshal(renderedIgnition)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return renderButane(mergedIgnition, config.Strict)
+}
+
+// raiseButaneVersion sets the version of the butane config to the given minimum version if it is lower.
+func raiseButaneVersion(butane map[string]interface{}, minVersion string) error {
+	current, ok := butane["version"].(string)
+	if !ok {
+		return fmt.Errorf("butane config has no version")
+	}
+	currentVersion, err := semver.NewVersion(current)
+	if err != nil {
+		return fmt.Errorf("failed to parse butane config version: %w", err)
+	}
+	if currentVersion.LessThan(*semver.New(minVersion)) {
+		butane["version"] = minVersion
+	}
+	return nil
+}
 
-		dnsConf := map[string]interface{}{
-			"storage": map[string]interface{}{
-				"files": []interface{}{map[string]interface{}{
-					"path": dnsConfFile,
-					"mode": fileMode,
-					"contents": map[string]interface{}{
-						"inline": strings.Join(dnsServers, "\n"),
-					},
-				}},
+// hostConfig returns the butane configuration which configures the host according to the given Config.
+func hostConfig(config *Config) map[string]interface{} {
+	var storageFiles []interface{}
+	for _, f := range append(hostFiles(config), caCertificateFiles(config)...) {
+		storageFiles = append(storageFiles, map[string]interface{}{
+			"path": f.Path,
+			"mode": f.Mode,
+			"contents": map[string]interface{}{
+				"inline": f.Contents,
 			},
+		})
+	}
+
+	var units []interface{}
+	if len(config.CACertificates) > 0 {
+		units = append(units, map[string]interface{}{
+			"name":     caCertificatesUnit,
+			"enabled":  true,
+			"contents": caCertificatesUnitContents,
+		})
+	}
+
+	var filesystems []interface{}
+	for _, fs := range config.Filesystems {
+		filesystem := map[string]interface{}{
+			"device":          fs.Device,
+			"format":          fs.Format,
+			"wipe_filesystem": fs.WipeFilesystem,
+		}
+		if fs.Label != "" {
+			filesystem["label"] = fs.Label
+		}
+		if fs.Path != "" {
+			filesystem["path"] = fs.Path
+			filesystem["with_mount_unit"] = true
 		}
+		if len(fs.MountOptions) > 0 {
+			filesystem["mount_options"] = fs.MountOptions
+		}
+		filesystems = append(filesystems, filesystem)
+	}
 
-		// merge dnsConfiguration with ignition content
-		if err := mergo.Merge(ignitionBase, dnsConf, mergo.WithAppendSlice); err != nil {
-			return "", fmt.Errorf("failed to merge dnsServer configuration with igntition content: %w", err)
+	hostConfig := map[string]interface{}{}
+	if len(storageFiles) > 0 || len(filesystems) > 0 {
+		storage := map[string]interface{}{}
+		if len(storageFiles) > 0 {
+			storage["files"] = storageFiles
+		}
+		if len(filesystems) > 0 {
+			storage["filesystems"] = filesystems
+		}
+		hostConfig["storage"] = storage
+	}
+	if len(config.KernelArguments) > 0 {
+		hostConfig["kernel_arguments"] = map[string]interface{}{
+			"should_exist": config.KernelArguments,
+		}
+	}
+	if users := passwdUsers(config); len(users) > 0 {
+		hostConfig["passwd"] = map[string]interface{}{
+			"users": users,
+		}
+	}
+	if len(units) > 0 {
+		hostConfig["systemd"] = map[string]interface{}{
+			"units": units,
 		}
 	}
 
-	mergedIgnition, err := yaml.Marshal(ignitionBase)
-	if err != nil {
-		return "", err
+	return hostConfig
+}
+
+// file is a file which should be written to the host of the Machine. ApplyCommand is run to apply the file
+// if it is written after the affected services have already been started, e.g. by cloud-init.
+type file struct {
+	Path         string
+	Mode         int
+	Contents     string
+	ApplyCommand []string
+}
+
+// hostFiles returns the files which configure the host according to the given Config.
+func hostFiles(config *Config) []file {
+	var files []file
+
+	if resolve := resolveSettings(config); len(resolve) > 0 {
+		files = append(files, file{
+			Path:         dnsConfFile,
+			Mode:         fileMode,
+			Contents:     strings.Join(append([]string{"[Resolve]"}, resolve...), "\n"),
+			ApplyCommand: []string{"systemctl", "try-restart", "systemd-resolved.service"},
+		})
 	}
 
-	tmpl, err := template.New("ignition").Funcs(sprig.HermeticTxtFuncMap()).Parse(string(mergedIgnition))
-	if err != nil {
-		return "", fmt.Errorf("failed creating ignition file: %w", err)
+	files = append(files, networkFiles(config)...)
+
+	if config.NTP != nil && len(config.NTP.Servers) > 0 {
+		files = append(files, file{
+			Path:         ntpConfFile,
+			Mode:         fileMode,
+			Contents:     fmt.Sprintf("[Time]\nNTP=%s\n", strings.Join(config.NTP.Servers, " ")),
+			ApplyCommand: []string{"systemctl", "try-restart", "systemd-timesyncd.service"},
+		})
 	}
-	buf := bytes.NewBufferString("")
-	err = tmpl.Execute(buf, config)
+
+	if env := proxyEnvironment(config.Proxy); len(env) > 0 {
+		quoted := make([]string, 0, len(env))
+		for _, v := range env {
+			quoted = append(quoted, fmt.Sprintf("%q", v))
+		}
+		files = append(files, file{
+			Path:         proxyConfFile,
+			Mode:         fileMode,
+			Contents:     fmt.Sprintf("[Manager]\nDefaultEnvironment=%s\n", strings.Join(quoted, " ")),
+			ApplyCommand: []string{"systemctl", "daemon-reload"},
+		})
+	}
+
+	if len(config.Sysctls) > 0 {
+		names := make([]string, 0, len(config.Sysctls))
+		for name := range config.Sysctls {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var sysctls strings.Builder
+		for _, name := range names {
+			fmt.Fprintf(&sysctls, "%s = %s\n", name, config.Sysctls[name])
+		}
+		files = append(files, file{
+			Path:         sysctlConfFile,
+			Mode:         fileMode,
+			Contents:     sysctls.String(),
+			ApplyCommand: []string{"systemctl", "restart", "systemd-sysctl.service"},
+		})
+	}
+
+	return files
+}
+
+// resolveSettings returns the global systemd-resolved settings of the given Config.
+func resolveSettings(config *Config) []string {
+	var settings []string
+	for _, v := range config.DnsServers {
+		settings = append(settings, fmt.Sprintf("%s%s", dnsEqualString, v.String()))
+	}
+
+	dns := config.DNS
+	if dns == nil {
+		return settings
+	}
+
+	for _, v := range dns.Servers {
+		settings = append(settings, fmt.Sprintf("%s%s", dnsEqualString, v.String()))
+	}
+	if len(dns.SearchDomains) > 0 {
+		settings = append(settings, fmt.Sprintf("Domains=%s", strings.Join(dns.SearchDomains, " ")))
+	}
+	if len(dns.FallbackServers) > 0 {
+		fallbackServers := make([]string, 0, len(dns.FallbackServers))
+		for _, v := range dns.FallbackServers {
+			fallbackServers = append(fallbackServers, v.String())
+		}
+		settings = append(settings, fmt.Sprintf("FallbackDNS=%s", strings.Join(fallbackServers, " ")))
+	}
+	if mode, ok := dnssecModes[dns.DNSSEC]; ok {
+		settings = append(settings, fmt.Sprintf("DNSSEC=%s", mode))
+	}
+	if mode, ok := dnsOverTLSModes[dns.DNSOverTLS]; ok {
+		settings = append(settings, fmt.Sprintf("DNSOverTLS=%s", mode))
+	}
+
+	return settings
+}
+
+// proxyEnvironment returns the environment variables which configure the given proxy. Both the upper and lower
+// case variants are set, as tools differ in which of them they respect.
+func proxyEnvironment(proxy *v1alpha1.Proxy) []string {
+	if proxy == nil {
+		return nil
+	}
+
+	var env []string
+	add := func(name, value string) {
+		if value != "" {
+			env = append(env, fmt.Sprintf("%s=%s", strings.ToUpper(name), value), fmt.Sprintf("%s=%s", name, value))
+		}
+	}
+	add("http_proxy", proxy.HTTPProxy)
+	add("https_proxy", proxy.HTTPSProxy)
+	add("no_proxy", strings.Join(proxy.NoProxy, ","))
+
+	return env
+}
+
+func renderButane(dataIn []byte, strict bool) (string, []string, error) {
+	dataOut, rpt, err := translateButane(dataIn, false)
 	if err != nil {
-		return "", fmt.Errorf("failed creating ignition file while executing template: %w", err)
+		return "", nil, err
+	}
+
+	if len(dataOut) > compressionThreshold {
+		if dataOut, rpt, err = translateButane(dataIn, true); err != nil {
+			return "", nil, err
+		}
 	}
 
-	ignition, err := renderButane(buf.Bytes())
+	// validate the rendered json against the ignition spec
+	_, validationReport, err := ignconfig.Parse(dataOut)
 	if err != nil {
-		return "", err
+		return "", nil, fmt.Errorf("rendered ignition is invalid: %w: %s", err, validationReport.String())
+	}
+	rpt.Merge(validationReport)
+
+	var warnings []string
+	for _, entry := range rpt.Entries {
+		if entry.Kind == report.Info {
+			continue
+		}
+		warnings = append(warnings, entry.String())
+	}
+
+	if strict && len(warnings) > 0 {
+		return "", warnings, fmt.Errorf("ignition has warnings in strict mode: %s", strings.Join(warnings, "; "))
 	}
 
-	return ignition, nil
+	return string(dataOut), warnings, nil
 }
-func renderButane(dataIn []byte) (string, error) {
+
+func translateButane(dataIn []byte, compress bool) ([]byte, report.Report, error) {
 	// render by butane to json
 	options := common.TranslateBytesOptions{
 		Raw:    true,
 		Pretty: false,
 	}
-	options.NoResourceAutoCompression = true
-	dataOut, _, err := buconfig.TranslateBytes(dataIn, options)
-	if err != nil {
-		return "", err
-	}
-	return string(dataOut), nil
+	// butane only uses gzip for inline resources if the compressed content is smaller
+	options.NoResourceAutoCompression = !compress
+	return buconfig.TranslateBytes(dataIn, options)
 }
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ignition
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
+)
+
+const (
+	networkDir        = "/etc/systemd/network"
+	networkFilePrefix = "10-ironcore-"
+)
+
+var (
+	dnssecModes = map[v1alpha1.DNSSECMode]string{
+		v1alpha1.DNSSECModeEnabled:        "yes",
+		v1alpha1.DNSSECModeDisabled:       "no",
+		v1alpha1.DNSSECModeAllowDowngrade: "allow-downgrade",
+	}
+	dnsOverTLSModes = map[v1alpha1.DNSOverTLSMode]string{
+		v1alpha1.DNSOverTLSModeEnabled:       "yes",
+		v1alpha1.DNSOverTLSModeDisabled:      "no",
+		v1alpha1.DNSOverTLSModeOpportunistic: "opportunistic",
+	}
+
+	unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+)
+
+// networkUnit is a systemd-networkd unit which configures a single interface of the host.
+type networkUnit struct {
+	Name          string
+	MTU           *int32
+	Addresses     []netip.Prefix
+	DNS           []string
+	SearchDomains []string
+	VLANs         []string
+	Routes        []v1alpha1.Route
+}
+
+// vlanNetDev is a systemd-networkd VLAN device which is created on the host.
+type vlanNetDev struct {
+	Name string
+	ID   int32
+	MTU  *int32
+}
+
+// networkUnits returns the network units and VLAN devices of the given Config in a stable order. Settings for the
+// same host interface are merged into a single unit, as systemd-networkd only applies the first matching unit.
+func networkUnits(config *Config) ([]*networkUnit, []vlanNetDev) {
+	var (
+		units   []*networkUnit
+		unitMap = map[string]*networkUnit{}
+		netDevs []vlanNetDev
+		unitFor = func(name string) *networkUnit {
+			if unit, ok := unitMap[name]; ok {
+				return unit
+			}
+			unit := &networkUnit{Name: name}
+			unitMap[name] = unit
+			units = append(units, unit)
+			return unit
+		}
+	)
+
+	for _, networkInterface := range config.NetworkInterfaces {
+		unit := unitFor(networkInterface.HostInterfaceName)
+		unit.MTU = networkInterface.MTU
+		unit.Routes = append(unit.Routes, networkInterface.Routes...)
+
+		for _, vlan := range networkInterface.VLANs {
+			name := vlan.HostInterfaceName()
+			unit.VLANs = append(unit.VLANs, name)
+			netDevs = append(netDevs, vlanNetDev{Name: name, ID: vlan.ID, MTU: vlan.MTU})
+
+			vlanUnit := unitFor(name)
+			vlanUnit.Addresses = vlan.Addresses
+			vlanUnit.Routes = append(vlanUnit.Routes, vlan.Routes...)
+		}
+	}
+
+	if config.DNS != nil {
+		for _, iface := range config.DNS.Interfaces {
+			unit := unitFor(iface.Name)
+			unit.SearchDomains = append(unit.SearchDomains, iface.SearchDomains...)
+			for _, v := range iface.Servers {
+				unit.DNS = append(unit.DNS, v.String())
+			}
+		}
+	}
+
+	return units, netDevs
+}
+
+// networkFiles returns the systemd-networkd files which configure the interfaces of the host. The units are sorted
+// before the units of the image and replace them for the matching interfaces, as systemd-networkd only applies the
+// first matching unit. Drop-ins can't be used as the names of the units of the image are unknown. Interfaces
+// without static addresses keep being configured via DHCP.
+func networkFiles(config *Config) []file {
+	var files []file
+
+	units, netDevs := networkUnits(config)
+	for _, netDev := range netDevs {
+		var contents strings.Builder
+		fmt.Fprintf(&contents, "[NetDev]\nName=%s\nKind=vlan\n", netDev.Name)
+		if netDev.MTU != nil {
+			fmt.Fprintf(&contents, "MTUBytes=%d\n", *netDev.MTU)
+		}
+		fmt.Fprintf(&contents, "\n[VLAN]\nId=%d\n", netDev.ID)
+
+		files = append(files, file{
+			Path:         networkFilePath(netDev.Name, "netdev"),
+			Mode:         fileMode,
+			Contents:     contents.String(),
+			ApplyCommand: []string{"networkctl", "reload"},
+		})
+	}
+
+	for _, unit := range units {
+		var contents strings.Builder
+		fmt.Fprintf(&contents, "[Match]\nName=%s\n", unit.Name)
+		if unit.MTU != nil {
+			fmt.Fprintf(&contents, "\n[Link]\nMTUBytes=%d\n", *unit.MTU)
+		}
+
+		contents.WriteString("\n[Network]\n")
+		if len(unit.Addresses) == 0 {
+			contents.WriteString("DHCP=yes\n")
+		}
+		for _, v := range unit.Addresses {
+			fmt.Fprintf(&contents, "Address=%s\n", v)
+		}
+		for _, v := range unit.DNS {
+			fmt.Fprintf(&contents, "DNS=%s\n", v)
+		}
+		if len(unit.SearchDomains) > 0 {
+			fmt.Fprintf(&contents, "Domains=%s\n", strings.Join(unit.SearchDomains, " "))
+		}
+		for _, v := range unit.VLANs {
+			fmt.Fprintf(&contents, "VLAN=%s\n", v)
+		}
+
+		for _, route := range unit.Routes {
+			fmt.Fprintf(&contents, "\n[Route]\nDestination=%s\n", route.Destination)
+			if route.Gateway != nil {
+				fmt.Fprintf(&contents, "Gateway=%s\n", route.Gateway)
+			}
+			if route.Metric != nil {
+				fmt.Fprintf(&contents, "Metric=%d\n", *route.Metric)
+			}
+		}
+
+		files = append(files, file{
+			Path:         networkFilePath(unit.Name, "network"),
+			Mode:         fileMode,
+			Contents:     contents.String(),
+			ApplyCommand: []string{"networkctl", "reload"},
+		})
+	}
+
+	return files
+}
+
+func networkFilePath(name, extension string) string {
+	return fmt.Sprintf("%s/%s%s.%s", networkDir, networkFilePrefix, unsafeFileNameChars.ReplaceAllString(name, "_"), extension)
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ignition
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
+)
+
+const (
+	// defaultUser is the user the SSHAuthorizedKeys are authorized for in an Ignition config.
+	defaultUser = "core"
+
+	caCertificatesDir          = "/usr/local/share/ca-certificates"
+	caCertificatesUnit         = "ironcore-ca-certificates.service"
+	caCertificatesUnitContents = `[Unit]
+Description=Update trusted CA certificates
+After=local-fs.target
+Before=cloud-config-init.service
+
+[Service]
+Type=oneshot
+ExecStart=/usr/sbin/update-ca-certificates
+
+[Install]
+WantedBy=multi-user.target
+`
+)
+
+// passwdUsers returns the butane passwd users for the SSHAuthorizedKeys and Users of the Config.
+func passwdUsers(config *Config) []interface{} {
+	var users []interface{}
+
+	if len(config.SSHAuthorizedKeys) > 0 {
+		users = append(users, map[string]interface{}{
+			"name":                defaultUser,
+			"ssh_authorized_keys": config.SSHAuthorizedKeys,
+		})
+	}
+
+	for _, u := range config.Users {
+		users = append(users, user(u))
+	}
+
+	return users
+}
+
+// cloudConfigUsers returns the cloud-config users for the Users of the Config. The default user of the image is
+// kept, so that the SSHAuthorizedKeys remain usable.
+func cloudConfigUsers(config *Config) []interface{} {
+	if len(config.Users) == 0 {
+		return nil
+	}
+
+	users := []interface{}{"default"}
+	for _, u := range config.Users {
+		users = append(users, user(u))
+	}
+
+	return users
+}
+
+// user returns the configuration of the given User, butane and cloud-init share the same keys.
+func user(u v1alpha1.User) map[string]interface{} {
+	user := map[string]interface{}{
+		"name": u.Name,
+	}
+	if len(u.Groups) > 0 {
+		user["groups"] = u.Groups
+	}
+	if len(u.SSHAuthorizedKeys) > 0 {
+		user["ssh_authorized_keys"] = u.SSHAuthorizedKeys
+	}
+	if u.Shell != "" {
+		user["shell"] = u.Shell
+	}
+	return user
+}
+
+// caCertificateFiles returns the files of the CACertificates which are picked up by update-ca-certificates.
+func caCertificateFiles(config *Config) []file {
+	var files []file
+	for i, cert := range config.CACertificates {
+		files = append(files, file{
+			Path:     path.Join(caCertificatesDir, fmt.Sprintf("ironcore-ca-%d.crt", i)),
+			Mode:     fileMode,
+			Contents: cert,
+		})
+	}
+	return files
+}
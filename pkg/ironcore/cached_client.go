@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ironcore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	computev1alpha1 "github.com/ironcore-dev/ironcore/api/compute/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// cachedClient serves reads of the cached object types from an informer cache and everything else from the
+// underlying client. Reads of namespaced objects outside of the cached namespace, e.g. for a namespace selected by a
+// machine class secret, go to the underlying client as well.
+type cachedClient struct {
+	client.Client
+	cache     cache.Cache
+	namespace string
+}
+
+// cacheSyncTimeout is the maximum time newCachedClient waits for the informer cache to sync.
+const cacheSyncTimeout = 2 * time.Minute
+
+// newCachedClient creates an informer cache for Machines, Secrets and MachinePools, where the namespaced types are
+// restricted to the given namespace, and waits until it is synced. The cache runs until the given context is done.
+// If the cache does not sync within cacheSyncTimeout, it is stopped and an error is returned.
+func newCachedClient(ctx context.Context, restConfig *rest.Config, c client.Client, namespace string) (client.Client, error) {
+	// the request timeout would end the long-running list and watch requests of the informers, the rate limiter is
+	// shared with the client
+	cacheConfig := rest.CopyConfig(restConfig)
+	cacheConfig.Timeout = 0
+	objCache, err := cache.New(cacheConfig, cache.Options{
+		Scheme:                      c.Scheme(),
+		Mapper:                      c.RESTMapper(),
+		DefaultNamespaces:           map[string]cache.Config{namespace: {}},
+		ReaderFailOnMissingInformer: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache: %w", err)
+	}
+	for _, obj := range []client.Object{&computev1alpha1.Machine{}, &corev1.Secret{}, &computev1alpha1.MachinePool{}} {
+		if _, err := objCache.GetInformer(ctx, obj); err != nil {
+			return nil, fmt.Errorf("failed to get informer for %T: %w", obj, err)
+		}
+	}
+
+	cacheCtx, stop := context.WithCancel(ctx)
+	go func() {
+		if err := objCache.Start(cacheCtx); err != nil {
+			klog.Errorf("Failed to run ironcore cache: %v", err)
+		}
+	}()
+
+	syncCtx, cancel := context.WithTimeout(cacheCtx, cacheSyncTimeout)
+	defer cancel()
+	if !objCache.WaitForCacheSync(syncCtx) {
+		stop()
+		return nil, fmt.Errorf("ironcore cache did not sync within %s: %w", cacheSyncTimeout, syncCtx.Err())
+	}
+	context.AfterFunc(ctx, stop)
+	return &cachedClient{Client: c, cache: objCache, namespace: namespace}, nil
+}
+
+func (c *cachedClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if c.isCached(obj, key.Namespace) {
+		return c.cache.Get(ctx, key, obj, opts...)
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *cachedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if c.isCached(list, listOpts.Namespace) {
+		return c.cache.List(ctx, list, opts...)
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *cachedClient) isCached(obj runtime.Object, namespace string) bool {
+	switch obj.(type) {
+	case *computev1alpha1.MachinePool, *computev1alpha1.MachinePoolList:
+		return true
+	case *computev1alpha1.Machine, *computev1alpha1.MachineList, *corev1.Secret, *corev1.SecretList:
+		return namespace == c.namespace
+	default:
+		return false
+	}
+}
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ironcore
+
+import (
+	storagev1alpha1 "github.com/ironcore-dev/ironcore/api/storage/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("CachedClient", func() {
+	ns, _, _ := SetupTest()
+
+	It("should serve reads of the cached namespace from the cache", func(ctx SpecContext) {
+		By("creating a secret in the cached namespace")
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:    ns.Name,
+				GenerateName: "cached-",
+			},
+			Data: map[string][]byte{"foo": []byte("bar")},
+		}
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+		By("creating a cached client for the namespace")
+		c, namespace, err := NewClientFromKubeconfig(ctx, newKubeconfig(cfg, ns.Name), scheme.Scheme, ClientOptions{Cache: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespace).To(Equal(ns.Name))
+		Expect(c).To(BeAssignableToTypeOf(&cachedClient{}))
+		objCache := c.(*cachedClient).cache
+
+		By("ensuring that the secret is read from the cache")
+		Expect(objCache.Get(ctx, client.ObjectKeyFromObject(secret), &corev1.Secret{})).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(secret), &corev1.Secret{})).To(Succeed())
+
+		By("ensuring that updates are eventually visible in the cache")
+		base := secret.DeepCopy()
+		secret.Data["foo"] = []byte("baz")
+		Expect(k8sClient.Patch(ctx, secret, client.MergeFrom(base))).To(Succeed())
+		Eventually(func(g Gomega) {
+			cached := &corev1.Secret{}
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(secret), cached)).To(Succeed())
+			g.Expect(cached.Data).To(HaveKeyWithValue("foo", []byte("baz")))
+		}).Should(Succeed())
+
+		By("ensuring that uncached types are read from the apiserver")
+		Expect(c.List(ctx, &storagev1alpha1.VolumeList{}, client.InNamespace(ns.Name))).To(Succeed())
+	})
+
+	It("should read objects outside of the cached namespace from the apiserver", func(ctx SpecContext) {
+		By("creating a secret in another namespace")
+		otherNs := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "testns-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, otherNs)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, otherNs)
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:    otherNs.Name,
+				GenerateName: "uncached-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+		By("creating a cached client for the test namespace")
+		c, _, err := NewClientFromKubeconfig(ctx, newKubeconfig(cfg, ns.Name), scheme.Scheme, ClientOptions{Cache: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("ensuring that the secret of the other namespace can be read")
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(secret), &corev1.Secret{})).To(Succeed())
+	})
+})
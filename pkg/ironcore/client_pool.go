@@ -0,0 +1,200 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ironcore
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	apiv1alpha1 "github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
+	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/validation"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClientOptions configures the clients created by NewClientFromKubeconfig.
+type ClientOptions struct {
+	// Cache serves reads of Machines, Secrets and MachinePools from an informer cache scoped to the namespace of the
+	// kubeconfig. All other reads and all writes go to the ironcore apiserver directly.
+	Cache bool
+	// QPS is the maximum number of requests per second to the ironcore apiserver. Zero uses the client-go default,
+	// a negative value disables the rate limiter.
+	QPS float32
+	// Burst is the number of requests that may exceed QPS. Zero uses the client-go default.
+	Burst int
+	// Timeout is the timeout of a single request to the ironcore apiserver. Zero means no timeout. It does not apply
+	// to the watches of the cache.
+	Timeout time.Duration
+	// UserAgent is sent with every request to the ironcore apiserver. Empty uses the client-go default.
+	UserAgent string
+}
+
+// NewClientFromKubeconfig creates a client for the ironcore cluster of the given kubeconfig and returns it together
+// with the namespace of the kubeconfig. If the client is cached, the cache runs until the given context is done.
+func NewClientFromKubeconfig(ctx context.Context, kubeconfigData []byte, scheme *runtime.Scheme, opts ClientOptions) (client.Client, string, error) {
+	kubeconfig, err := clientcmd.Load(kubeconfigData)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read ironcore cluster kubeconfig: %w", err)
+	}
+	clientConfig := clientcmd.NewDefaultClientConfig(*kubeconfig, nil)
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to get ironcore cluster rest config: %w", err)
+	}
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get namespace from ironcore kubeconfig: %w", err)
+	}
+	applyClientOptions(restConfig, opts)
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create client: %w", err)
+	}
+	if opts.Cache {
+		c, err = newCachedClient(ctx, restConfig, c, namespace)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return c, namespace, nil
+}
+
+// clientIdleTimeout is the time after which an unused client is removed from the client pool, so that the clients of
+// rotated credentials don't pile up.
+const clientIdleTimeout = 30 * time.Minute
+
+// clientPool caches the ironcore clients created from the credentials of machine class secrets, keyed by the hash
+// of the kubeconfig, so that every machine class secret with the same credentials shares one client.
+type clientPool struct {
+	scheme      *runtime.Scheme
+	opts        ClientOptions
+	idleTimeout time.Duration
+
+	mu      sync.Mutex
+	clients map[string]*pooledClient
+}
+
+type pooledClient struct {
+	client    client.Client
+	namespace string
+	lastUsed  time.Time
+}
+
+func newClientPool(scheme *runtime.Scheme, opts ClientOptions) *clientPool {
+	// clients for machine class secrets are not cached, otherwise every distinct secret would start its own informers
+	opts.Cache = false
+	return &clientPool{
+		scheme:      scheme,
+		opts:        opts,
+		idleTimeout: clientIdleTimeout,
+		clients:     make(map[string]*pooledClient),
+	}
+}
+
+// get returns the client and the namespace of the given kubeconfig, creating the client on first use. Clients which
+// have not been used for the idle timeout are removed from the pool.
+func (p *clientPool) get(kubeconfig []byte) (client.Client, string, error) {
+	key := fmt.Sprintf("%x", sha256.Sum256(kubeconfig))
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for pooledKey, pooled := range p.clients {
+		if pooledKey != key && now.Sub(pooled.lastUsed) > p.idleTimeout {
+			klog.V(2).Infof("Removing idle ironcore client %s for namespace %q", shortHash(pooledKey), pooled.namespace)
+			delete(p.clients, pooledKey)
+		}
+	}
+
+	if pooled, ok := p.clients[key]; ok {
+		pooled.lastUsed = now
+		return pooled.client, pooled.namespace, nil
+	}
+
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read ironcore cluster kubeconfig: %w", err)
+	}
+	if err := validation.ValidateKubeconfig(config, field.NewPath(apiv1alpha1.SecretKubeconfigKey)).ToAggregate(); err != nil {
+		return nil, "", fmt.Errorf("unsupported ironcore cluster kubeconfig: %w", err)
+	}
+
+	c, namespace, err := NewClientFromKubeconfig(context.Background(), kubeconfig, p.scheme, p.opts)
+	if err != nil {
+		return nil, "", err
+	}
+	klog.V(2).Infof("Created ironcore client %s for namespace %q", shortHash(key), namespace)
+	p.clients[key] = &pooledClient{client: c, namespace: namespace, lastUsed: now}
+	return c, namespace, nil
+}
+
+// forSecret returns the driver for the ironcore cluster and namespace selected by the given machine class secret.
+// Secrets without ironcore credentials use the client and namespace the driver was created with.
+func (d *ironcoreDriver) forSecret(secret *corev1.Secret) (*ironcoreDriver, error) {
+	kubeconfig, err := getKubeconfigFromSecret(secret)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to get ironcore credentials from secret %s: %v", client.ObjectKeyFromObject(secret), err))
+	}
+	namespace := string(secret.Data[apiv1alpha1.SecretNamespaceKey])
+	if len(kubeconfig) == 0 && namespace == "" {
+		return d, nil
+	}
+
+	drv := *d
+	if len(kubeconfig) > 0 {
+		c, kubeconfigNamespace, err := d.clientPool.get(kubeconfig)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to get ironcore client from secret %s: %v", client.ObjectKeyFromObject(secret), err))
+		}
+		drv.IroncoreClient = c
+		drv.IroncoreNamespace = kubeconfigNamespace
+	}
+	if namespace != "" {
+		drv.IroncoreNamespace = namespace
+	}
+	if drv.IroncoreNamespace == "" {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("no ironcore namespace found in secret %s", client.ObjectKeyFromObject(secret)))
+	}
+	return &drv, nil
+}
+
+// getKubeconfigFromSecret returns the kubeconfig of the machine class secret, which is either contained in the
+// secret or built from its token, server and CA bundle. It returns nil if the secret contains no credentials.
+func getKubeconfigFromSecret(secret *corev1.Secret) ([]byte, error) {
+	if kubeconfig := secret.Data[apiv1alpha1.SecretKubeconfigKey]; len(kubeconfig) > 0 {
+		return kubeconfig, nil
+	}
+
+	token := secret.Data[apiv1alpha1.SecretTokenKey]
+	if len(token) == 0 {
+		return nil, nil
+	}
+
+	const name = "ironcore"
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters[name] = &clientcmdapi.Cluster{
+		Server:                   string(secret.Data[apiv1alpha1.SecretServerKey]),
+		CertificateAuthorityData: secret.Data[apiv1alpha1.SecretCAKey],
+	}
+	kubeconfig.AuthInfos[name] = &clientcmdapi.AuthInfo{
+		Token: string(token),
+	}
+	kubeconfig.Contexts[name] = &clientcmdapi.Context{
+		Cluster:  name,
+		AuthInfo: name,
+	}
+	kubeconfig.CurrentContext = name
+	return clientcmd.Write(*kubeconfig)
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ironcore
+
+import (
+	"time"
+
+	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var _ = Describe("ClientPool", func() {
+	It("should share the client of equal credentials", func() {
+		pool := newClientPool(scheme.Scheme, ClientOptions{})
+
+		By("getting a client for the same kubeconfig twice")
+		c1, namespace1, err := pool.get(newKubeconfig(cfg, "foo"))
+		Expect(err).NotTo(HaveOccurred())
+		c2, namespace2, err := pool.get(newKubeconfig(cfg, "foo"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c1).To(BeIdenticalTo(c2))
+		Expect(namespace1).To(Equal("foo"))
+		Expect(namespace2).To(Equal("foo"))
+
+		By("getting a client for another kubeconfig")
+		c3, namespace3, err := pool.get(newKubeconfig(cfg, "bar"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c3).NotTo(BeIdenticalTo(c1))
+		Expect(namespace3).To(Equal("bar"))
+	})
+
+	It("should remove idle clients", func() {
+		pool := newClientPool(scheme.Scheme, ClientOptions{})
+		pool.idleTimeout = time.Millisecond
+
+		By("getting a client and waiting for the idle timeout")
+		c1, _, err := pool.get(newKubeconfig(cfg, "foo"))
+		Expect(err).NotTo(HaveOccurred())
+		time.Sleep(2 * pool.idleTimeout)
+
+		By("ensuring that the idle client is removed when getting another client")
+		_, _, err = pool.get(newKubeconfig(cfg, "bar"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pool.clients).To(HaveLen(1))
+
+		By("ensuring that a new client is created for the removed credentials")
+		c2, _, err := pool.get(newKubeconfig(cfg, "foo"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c2).NotTo(BeIdenticalTo(c1))
+	})
+
+	It("should reject kubeconfigs with credentials outside of the kubeconfig", func() {
+		pool := newClientPool(scheme.Scheme, ClientOptions{})
+
+		kubeconfig := clientcmdapi.NewConfig()
+		kubeconfig.Clusters["ironcore"] = &clientcmdapi.Cluster{Server: "https://ironcore.example.com"}
+		kubeconfig.AuthInfos["ironcore"] = &clientcmdapi.AuthInfo{
+			Exec: &clientcmdapi.ExecConfig{APIVersion: "client.authentication.k8s.io/v1", Command: "/bin/sh"},
+		}
+		kubeconfig.Contexts["ironcore"] = &clientcmdapi.Context{Cluster: "ironcore", AuthInfo: "ironcore"}
+		kubeconfig.CurrentContext = "ironcore"
+		data, err := clientcmd.Write(*kubeconfig)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _, err = pool.get(data)
+		Expect(err).To(MatchError(ContainSubstring("exec is not supported")))
+		Expect(pool.clients).To(BeEmpty())
+	})
+
+	It("should build a kubeconfig from the token, server and ca of the secret", func() {
+		kubeconfigData, err := getKubeconfigFromSecret(&corev1.Secret{
+			Data: map[string][]byte{
+				v1alpha1.SecretTokenKey:  []byte("my-token"),
+				v1alpha1.SecretServerKey: []byte("https://ironcore.example.com"),
+				v1alpha1.SecretCAKey:     []byte("my-ca"),
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		kubeconfig, err := clientcmd.Load(kubeconfigData)
+		Expect(err).NotTo(HaveOccurred())
+		restConfig, err := clientcmd.NewDefaultClientConfig(*kubeconfig, nil).ClientConfig()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restConfig.Host).To(Equal("https://ironcore.example.com"))
+		Expect(restConfig.BearerToken).To(Equal("my-token"))
+		Expect(restConfig.CAData).To(Equal([]byte("my-ca")))
+	})
+
+	It("should return no kubeconfig for a secret without credentials", func() {
+		Expect(getKubeconfigFromSecret(&corev1.Secret{
+			Data: map[string][]byte{"userData": []byte("foo")},
+		})).To(BeNil())
+	})
+})
@@ -12,6 +12,7 @@ import (
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	commonv1alpha1ac "github.com/ironcore-dev/ironcore/client-go/applyconfigurations/common/v1alpha1"
 	computev1alpha1ac "github.com/ironcore-dev/ironcore/client-go/applyconfigurations/compute/v1alpha1"
 	ipamv1alpha1ac "github.com/ironcore-dev/ironcore/client-go/applyconfigurations/ipam/v1alpha1"
 	networkingv1alpha1ac "github.com/ironcore-dev/ironcore/client-go/applyconfigurations/networking/v1alpha1"
@@ -22,12 +23,14 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
-	"k8s.io/klog"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	commonv1alpha1 "github.com/ironcore-dev/ironcore/api/common/v1alpha1"
 	computev1alpha1 "github.com/ironcore-dev/ironcore/api/compute/v1alpha1"
 	corev1alpha1 "github.com/ironcore-dev/ironcore/api/core/v1alpha1"
+	storagev1alpha1 "github.com/ironcore-dev/ironcore/api/storage/v1alpha1"
 	apiv1alpha1 "github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
 	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/validation"
 	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/ignition"
@@ -50,6 +53,11 @@ func (d *ironcoreDriver) CreateMachine(ctx context.Context, req *driver.CreateMa
 		return nil, err
 	}
 
+	d, err = d.forSecret(req.Secret)
+	if err != nil {
+		return nil, err
+	}
+
 	ironcoreMachine, err := d.applyIronCoreMachine(ctx, req, providerSpec)
 	if err != nil {
 		return nil, err
@@ -73,7 +81,16 @@ func (d *ironcoreDriver) applyIronCoreMachine(ctx context.Context, req *driver.C
 		return nil, err
 	}
 
-	ignitionSecretApplyConfig, ignitionSecretKey, err := d.buildIgnitionSecretApplyConfig(ctx, req, providerSpec, userData)
+	machineName, err := getIroncoreMachineName(req.Machine, providerSpec)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.checkMachineNameConflict(ctx, req.Machine, machineName); err != nil {
+		return nil, err
+	}
+	labels := getObjectLabels(req.Machine.Name, req.MachineClass, providerSpec.Labels)
+
+	ignitionSecretApplyConfig, ignitionSecretKey, ignitionWarnings, err := d.buildIgnitionSecretApplyConfig(ctx, req, machineName, providerSpec, labels, userData)
 	if err != nil {
 		return nil, err
 	}
@@ -83,56 +100,137 @@ func (d *ironcoreDriver) applyIronCoreMachine(ctx context.Context, req *driver.C
 		return nil, err
 	}
 
+	if err := d.checkEncryptionSecrets(ctx, providerSpec); err != nil {
+		return nil, err
+	}
+
 	if err := d.IroncoreClient.Apply(ctx, ignitionSecretApplyConfig, client.FieldOwner(fieldOwner), client.ForceOwnership); err != nil {
-		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to apply ignition secret for machine %s: %v", req.Machine.Name, err))
+		return nil, apiError(err, "failed to apply ignition secret for machine %s", req.Machine.Name)
+	}
+
+	bootIgnitionHash, err := d.getBootIgnitionHash(ctx, machineName, ignitionSecretApplyConfig.Annotations[IgnitionHashAnnotation])
+	if err != nil {
+		return nil, err
 	}
 
-	machineApplyConfig := d.buildMachineApplyConfig(ctx, req, providerSpec, ignitionSecretKey, machinePoolRef, machinePoolSelector)
+	if err := d.ensurePersistentRootVolume(ctx, machineName, providerSpec, labels); err != nil {
+		return nil, err
+	}
+
+	machineApplyConfig := d.buildMachineApplyConfig(ctx, req, machineName, providerSpec, labels, ignitionSecretKey, machinePoolRef, machinePoolSelector)
+	if bootIgnitionHash != "" {
+		machineApplyConfig.WithAnnotations(map[string]string{IgnitionHashAnnotation: bootIgnitionHash})
+	}
 	if err := d.IroncoreClient.Apply(ctx, machineApplyConfig, client.FieldOwner(fieldOwner), client.ForceOwnership); err != nil {
-		return nil, status.Error(codes.Internal, fmt.Sprintf("error applying ironcore machine: %s", err.Error()))
+		return nil, apiError(err, "error applying ironcore machine")
 
 	}
 
-	return &computev1alpha1.Machine{
+	// the apply returns the applied Machine, a read right after the apply may not find it yet in a cached client
+	ironcoreMachine := &computev1alpha1.Machine{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      req.Machine.Name,
+			Name:      machineName,
 			Namespace: d.IroncoreNamespace,
+			UID:       ptr.Deref(machineApplyConfig.UID, ""),
 		},
-	}, nil
+	}
+
+	if len(ignitionWarnings) > 0 {
+		d.recordIgnitionWarnings(ctx, ironcoreMachine, ignitionWarnings)
+	}
+
+	return ironcoreMachine, nil
+}
+
+// getBootIgnitionHash returns the ignition hash which is recorded on the ironcore Machine. A new Machine boots
+// from the given ignition, an existing Machine keeps the hash of the ignition it was created with, so that
+// GetMachineStatus is able to detect that the ignition changed afterward.
+func (d *ironcoreDriver) getBootIgnitionHash(ctx context.Context, machineName, hash string) (string, error) {
+	machine := &computev1alpha1.Machine{}
+	if err := d.IroncoreClient.Get(ctx, client.ObjectKey{Namespace: d.IroncoreNamespace, Name: machineName}, machine); err != nil {
+		if apierrors.IsNotFound(err) {
+			return hash, nil
+		}
+		return "", apiError(err, "failed to get ironcore machine %s", machineName)
+	}
+
+	return machine.Annotations[IgnitionHashAnnotation], nil
+}
+
+// recordIgnitionWarnings logs the warnings reported while rendering the ignition and records them as events on the
+// ironcore Machine. Failing to record the events does not fail the machine creation.
+func (d *ironcoreDriver) recordIgnitionWarnings(ctx context.Context, ironcoreMachine *computev1alpha1.Machine, warnings []string) {
+	for _, warning := range warnings {
+		klog.Warningf("Ignition for machine %s has a warning: %s", ironcoreMachine.Name, warning)
+		d.recordEvent(ctx, ironcoreMachine, corev1.EventTypeWarning, "IgnitionWarning", warning)
+	}
 }
 
 func (d *ironcoreDriver) getUserData(req *driver.CreateMachineRequest) ([]byte, error) {
 	userData, ok := req.Secret.Data["userData"]
 	if !ok {
-		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to find user-data in machine secret %s", client.ObjectKeyFromObject(req.Secret)))
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to find user-data in machine secret %s", client.ObjectKeyFromObject(req.Secret)))
 	}
 
 	return userData, nil
 }
 
-func (d *ironcoreDriver) buildIgnitionSecretApplyConfig(ctx context.Context, req *driver.CreateMachineRequest, providerSpec *apiv1alpha1.ProviderSpec, userData []byte) (*corev1ac.SecretApplyConfiguration, string, error) {
+func (d *ironcoreDriver) buildIgnitionSecretApplyConfig(ctx context.Context, req *driver.CreateMachineRequest, machineName string, providerSpec *apiv1alpha1.ProviderSpec, labels map[string]string, userData []byte) (*corev1ac.SecretApplyConfiguration, string, []string, error) {
 	config := &ignition.Config{
-		Hostname:         req.Machine.Name,
+		Hostname:         machineName,
 		UserData:         string(userData),
 		Ignition:         providerSpec.Ignition,
 		DnsServers:       providerSpec.DnsServers,
+		DNS:              providerSpec.DNS,
 		IgnitionOverride: providerSpec.IgnitionOverride,
+		Strict:           providerSpec.IgnitionStrict,
+		Bootstrap:        providerSpec.Bootstrap,
+
+		SSHAuthorizedKeys: providerSpec.SSHAuthorizedKeys,
+		Users:             providerSpec.Users,
+		CACertificates:    providerSpec.CACertificates,
+		KernelArguments:   providerSpec.KernelArguments,
+		Filesystems:       getFilesystems(providerSpec),
+		NetworkInterfaces: providerSpec.NetworkInterfaces,
+		NTP:               providerSpec.NTP,
+		Proxy:             providerSpec.Proxy,
+		Sysctls:           providerSpec.Sysctls,
 	}
-	ignitionContent, err := ignition.File(config)
+	ignitionContent, warnings, err := renderBootstrapData(config, providerSpec.BootstrapFormat)
 	if err != nil {
-		return nil, "", status.Error(codes.Internal, fmt.Sprintf("failed to create ignition file for machine %s: %v", req.Machine.Name, err))
+		return nil, "", nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to create ignition file for machine %s: %v", req.Machine.Name, err))
+	}
+
+	// the api server rejects secrets exceeding the maximum size, so fail early with a permanent error
+	if size := len(ignitionContent); size > corev1.MaxSecretSize {
+		return nil, "", nil, status.Error(codes.InvalidArgument, fmt.Sprintf("ignition for machine %s exceeds the maximum secret size: %d > %d bytes", req.Machine.Name, size, corev1.MaxSecretSize))
 	}
 
 	ignitionSecretKey := getIgnitionKeyOrDefault(providerSpec.IgnitionSecretKey)
 
 	secret := corev1ac.Secret(
-		d.getIgnitionNameForMachine(ctx, req.Machine.Name),
+		d.getIgnitionNameForMachine(ctx, machineName),
 		d.IroncoreNamespace,
-	).WithData(map[string][]byte{
+	).WithLabels(labels).WithAnnotations(providerSpec.Annotations).WithAnnotations(map[string]string{
+		IgnitionHashAnnotation: ignitionHash([]byte(ignitionContent)),
+	}).WithData(map[string][]byte{
 		ignitionSecretKey: []byte(ignitionContent),
 	})
 
-	return secret, ignitionSecretKey, nil
+	return secret, ignitionSecretKey, warnings, nil
+}
+
+// renderBootstrapData renders the bootstrap data of the Machine in the requested format
+func renderBootstrapData(config *ignition.Config, format apiv1alpha1.BootstrapFormat) (string, []string, error) {
+	switch format {
+	case apiv1alpha1.BootstrapFormatCloudConfig:
+		content, err := ignition.CloudConfig(config)
+		return content, nil, err
+	case apiv1alpha1.BootstrapFormatRaw:
+		return config.UserData, nil, nil
+	default:
+		return ignition.File(config)
+	}
 }
 
 func (d *ironcoreDriver) resolveMachinePool(ctx context.Context, zone string, region string) (*corev1.LocalObjectReference, map[string]string, error) {
@@ -140,7 +238,7 @@ func (d *ironcoreDriver) resolveMachinePool(ctx context.Context, zone string, re
 
 	if err := d.IroncoreClient.Get(ctx, client.ObjectKey{Name: zone}, pool); err != nil {
 		if !apierrors.IsNotFound(err) {
-			return nil, nil, status.Error(codes.Internal, fmt.Sprintf("error checking machine pool %q: %s", zone, err.Error()))
+			return nil, nil, apiError(err, "error checking machine pool %q", zone)
 		}
 
 		machinePoolSelector := map[string]string{
@@ -156,23 +254,26 @@ func (d *ironcoreDriver) resolveMachinePool(ctx context.Context, zone string, re
 	return &corev1.LocalObjectReference{Name: zone}, nil, nil
 }
 
-func (d *ironcoreDriver) buildMachineApplyConfig(ctx context.Context, req *driver.CreateMachineRequest, providerSpec *apiv1alpha1.ProviderSpec, ignitionSecretKey string, machinePoolRef *corev1.LocalObjectReference, machinePoolSelector map[string]string) *computev1alpha1ac.MachineApplyConfiguration {
-	volumes := d.buildMachineVolumes(providerSpec)
+func (d *ironcoreDriver) buildMachineApplyConfig(ctx context.Context, req *driver.CreateMachineRequest, machineName string, providerSpec *apiv1alpha1.ProviderSpec, labels map[string]string, ignitionSecretKey string, machinePoolRef *corev1.LocalObjectReference, machinePoolSelector map[string]string) *computev1alpha1ac.MachineApplyConfiguration {
+	volumes := d.buildMachineVolumes(machineName, providerSpec, labels)
 
 	spec := computev1alpha1ac.MachineSpec().
 		WithMachineClassRef(corev1.LocalObjectReference{Name: req.MachineClass.NodeTemplate.InstanceType}).
 		WithPower(computev1alpha1.PowerOn).
 		WithNetworkInterfaces(computev1alpha1ac.NetworkInterface().
-			WithName("nic").
+			WithName(apiv1alpha1.PrimaryNetworkInterfaceName).
 			WithEphemeral(computev1alpha1ac.EphemeralNetworkInterfaceSource().
 				WithNetworkInterfaceTemplate(networkingv1alpha1ac.NetworkInterfaceTemplateSpec().
-					WithLabels(providerSpec.Labels).
+					WithLabels(labels).
+					WithAnnotations(providerSpec.Annotations).
 					WithSpec(networkingv1alpha1ac.NetworkInterfaceSpec().
 						WithNetworkRef(corev1.LocalObjectReference{Name: providerSpec.NetworkName}).
 						WithIPFamilies(corev1.IPv4Protocol).
 						WithIPs(networkingv1alpha1ac.IPSource().
 							WithEphemeral(networkingv1alpha1ac.EphemeralPrefixSource().
 								WithPrefixTemplate(ipamv1alpha1ac.PrefixTemplateSpec().
+									WithLabels(labels).
+									WithAnnotations(providerSpec.Annotations).
 									WithSpec(ipamv1alpha1ac.PrefixSpec().
 										WithPrefixLength(32).
 										WithParentRef(corev1.LocalObjectReference{Name: providerSpec.PrefixName}),
@@ -185,7 +286,7 @@ func (d *ironcoreDriver) buildMachineApplyConfig(ctx context.Context, req *drive
 			),
 		).
 		WithIgnitionRef(commonv1alpha1.SecretKeySelector{
-			Name: d.getIgnitionNameForMachine(ctx, req.Machine.Name),
+			Name: d.getIgnitionNameForMachine(ctx, machineName),
 			Key:  ignitionSecretKey,
 		}).
 		WithVolumes(volumes...)
@@ -198,37 +299,189 @@ func (d *ironcoreDriver) buildMachineApplyConfig(ctx context.Context, req *drive
 		spec.WithMachinePoolSelector(machinePoolSelector)
 	}
 
-	return computev1alpha1ac.Machine(req.Machine.Name, d.IroncoreNamespace).
-		WithLabels(providerSpec.Labels).
+	return computev1alpha1ac.Machine(machineName, d.IroncoreNamespace).
+		WithLabels(labels).
+		WithAnnotations(providerSpec.Annotations).
+		WithAnnotations(map[string]string{MachineNameAnnotation: req.Machine.Name}).
 		WithSpec(spec)
 }
 
-func (d *ironcoreDriver) buildMachineVolumes(providerSpec *apiv1alpha1.ProviderSpec) []*computev1alpha1ac.VolumeApplyConfiguration {
+func (d *ironcoreDriver) buildMachineVolumes(machineName string, providerSpec *apiv1alpha1.ProviderSpec, labels map[string]string) []*computev1alpha1ac.VolumeApplyConfiguration {
+	volumes := []*computev1alpha1ac.VolumeApplyConfiguration{d.buildRootVolume(machineName, providerSpec, labels)}
+	for i, dataDisk := range providerSpec.DataDisks {
+		volumes = append(volumes, d.buildDataVolume(i, dataDisk, labels, providerSpec.Annotations))
+	}
+	return volumes
+}
+
+func (d *ironcoreDriver) buildRootVolume(machineName string, providerSpec *apiv1alpha1.ProviderSpec, labels map[string]string) *computev1alpha1ac.VolumeApplyConfiguration {
 	if providerSpec.RootDisk == nil {
-		return []*computev1alpha1ac.VolumeApplyConfiguration{computev1alpha1ac.Volume().
-			WithName("root").
+		return computev1alpha1ac.Volume().
+			WithName(apiv1alpha1.RootDiskVolumeName).
 			WithLocalDisk(computev1alpha1ac.LocalDiskVolumeSource().
 				WithImage(providerSpec.Image),
+			)
+	}
+
+	if providerSpec.RootDisk.Persistent {
+		return computev1alpha1ac.Volume().
+			WithName(apiv1alpha1.RootDiskVolumeName).
+			WithVolumeRef(corev1.LocalObjectReference{Name: getRootVolumeNameForMachine(machineName)})
+	}
+
+	return computev1alpha1ac.Volume().
+		WithName(apiv1alpha1.RootDiskVolumeName).
+		WithEphemeral(computev1alpha1ac.EphemeralVolumeSource().
+			WithVolumeTemplate(storagev1alpha1ac.VolumeTemplateSpec().
+				WithLabels(labels).
+				WithLabels(providerSpec.RootDisk.Labels).
+				WithAnnotations(providerSpec.Annotations).
+				WithSpec(buildRootVolumeSpec(providerSpec)),
 			),
+		)
+}
+
+// buildRootVolumeSpec builds the spec of the root disk Volume, which is shared by ephemeral and persistent root disks.
+func buildRootVolumeSpec(providerSpec *apiv1alpha1.ProviderSpec) *storagev1alpha1ac.VolumeSpecApplyConfiguration {
+	volumeSpec := storagev1alpha1ac.VolumeSpec().
+		WithVolumeClassRef(corev1.LocalObjectReference{Name: providerSpec.RootDisk.VolumeClassName}).
+		WithResources(corev1alpha1.ResourceList{corev1alpha1.ResourceStorage: providerSpec.RootDisk.Size}).
+		WithDataSource(buildRootVolumeDataSource(providerSpec))
+	return withVolumeOptions(volumeSpec, providerSpec.RootDisk.EncryptionSecretName, providerSpec.RootDisk.Tolerations)
+}
+
+// withVolumeOptions sets the encryption and the tolerations which are shared by the root disk and the data disks.
+func withVolumeOptions(volumeSpec *storagev1alpha1ac.VolumeSpecApplyConfiguration, encryptionSecretName string, tolerations []apiv1alpha1.Toleration) *storagev1alpha1ac.VolumeSpecApplyConfiguration {
+	if encryptionSecretName != "" {
+		volumeSpec.WithEncryption(storagev1alpha1ac.VolumeEncryption().
+			WithSecretRef(corev1.LocalObjectReference{Name: encryptionSecretName}),
+		)
+	}
+	for _, toleration := range tolerations {
+		tolerationApplyConfig := commonv1alpha1ac.Toleration().
+			WithKey(toleration.Key).
+			WithValue(toleration.Value)
+		if toleration.Operator != "" {
+			tolerationApplyConfig.WithOperator(commonv1alpha1.TolerationOperator(toleration.Operator))
+		}
+		if toleration.Effect != "" {
+			tolerationApplyConfig.WithEffect(commonv1alpha1.TaintEffect(toleration.Effect))
+		}
+		volumeSpec.WithTolerations(tolerationApplyConfig)
+	}
+	return volumeSpec
+}
+
+// checkEncryptionSecrets ensures that the encryption secrets referenced by the disks exist, as the Volumes would
+// otherwise never become available.
+func (d *ironcoreDriver) checkEncryptionSecrets(ctx context.Context, providerSpec *apiv1alpha1.ProviderSpec) error {
+	var secretNames []string
+	if providerSpec.RootDisk != nil && providerSpec.RootDisk.EncryptionSecretName != "" {
+		secretNames = append(secretNames, providerSpec.RootDisk.EncryptionSecretName)
+	}
+	for _, dataDisk := range providerSpec.DataDisks {
+		if dataDisk.EncryptionSecretName != "" {
+			secretNames = append(secretNames, dataDisk.EncryptionSecretName)
 		}
 	}
 
-	return []*computev1alpha1ac.VolumeApplyConfiguration{computev1alpha1ac.Volume().
-		WithName("root").
+	for _, secretName := range secretNames {
+		secretKey := client.ObjectKey{Namespace: d.IroncoreNamespace, Name: secretName}
+		if err := d.IroncoreClient.Get(ctx, secretKey, &corev1.Secret{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return status.Error(codes.InvalidArgument, fmt.Sprintf("encryption secret %s does not exist", secretKey))
+			}
+			return apiError(err, "failed to get encryption secret %s", secretKey)
+		}
+	}
+
+	return nil
+}
+
+// buildRootVolumeDataSource returns the data source of the root disk Volume, which is either a VolumeSnapshot or
+// the operating system image.
+func buildRootVolumeDataSource(providerSpec *apiv1alpha1.ProviderSpec) *storagev1alpha1ac.VolumeDataSourceApplyConfiguration {
+	if providerSpec.RootDisk.VolumeSnapshotName != "" {
+		return storagev1alpha1ac.VolumeDataSource().
+			WithVolumeSnapshotRef(corev1.LocalObjectReference{Name: providerSpec.RootDisk.VolumeSnapshotName})
+	}
+
+	return storagev1alpha1ac.VolumeDataSource().
+		WithOSImage(storagev1alpha1ac.OSDataSource().
+			WithImage(providerSpec.Image),
+		)
+}
+
+// ensurePersistentRootVolume creates the persistent root disk Volume of the machine if it does not exist yet. An
+// existing Volume is reused as is, so that the machine keeps its root disk when it is created again. It is only reused
+// if it was created for the same machine class.
+func (d *ironcoreDriver) ensurePersistentRootVolume(ctx context.Context, machineName string, providerSpec *apiv1alpha1.ProviderSpec, labels map[string]string) error {
+	if providerSpec.RootDisk == nil || !providerSpec.RootDisk.Persistent {
+		return nil
+	}
+
+	volumeKey := client.ObjectKey{Namespace: d.IroncoreNamespace, Name: getRootVolumeNameForMachine(machineName)}
+	volume := &storagev1alpha1.Volume{}
+	if err := d.IroncoreClient.Get(ctx, volumeKey, volume); err == nil {
+		for _, key := range []string{MachineClassLabel, MachineNamespaceLabel} {
+			if volume.Labels[key] != labels[key] {
+				return status.Error(codes.InvalidArgument, fmt.Sprintf("persistent root volume %s belongs to another machine class: label %s is %q instead of %q", volumeKey, key, volume.Labels[key], labels[key]))
+			}
+		}
+		klog.V(3).Infof("Reusing persistent root volume %s for machine %s", volumeKey, machineName)
+		return nil
+	} else if !apierrors.IsNotFound(err) {
+		return apiError(err, "failed to get persistent root volume %s", volumeKey)
+	}
+
+	volumeApplyConfig := storagev1alpha1ac.Volume(volumeKey.Name, volumeKey.Namespace).
+		WithLabels(labels).
+		WithLabels(providerSpec.RootDisk.Labels).
+		WithAnnotations(providerSpec.Annotations).
+		WithSpec(buildRootVolumeSpec(providerSpec))
+	if err := d.IroncoreClient.Apply(ctx, volumeApplyConfig, client.FieldOwner(fieldOwner), client.ForceOwnership); err != nil {
+		return apiError(err, "failed to apply persistent root volume %s", volumeKey)
+	}
+
+	return nil
+}
+
+// getFilesystems returns the filesystems of the ProviderSpec together with the block device of their data disk, which
+// is identified by the serial of the virtio disk.
+func getFilesystems(providerSpec *apiv1alpha1.ProviderSpec) []ignition.Filesystem {
+	var filesystems []ignition.Filesystem
+	for _, filesystem := range providerSpec.Filesystems {
+		for i, dataDisk := range providerSpec.DataDisks {
+			if dataDisk.Name == filesystem.VolumeName {
+				filesystems = append(filesystems, ignition.Filesystem{
+					Filesystem: filesystem,
+					Device:     "/dev/disk/by-id/virtio-" + apiv1alpha1.DataDiskDevice(i),
+				})
+			}
+		}
+	}
+	return filesystems
+}
+
+func (d *ironcoreDriver) buildDataVolume(index int, dataDisk apiv1alpha1.DataDisk, labels, annotations map[string]string) *computev1alpha1ac.VolumeApplyConfiguration {
+	volumeSpec := storagev1alpha1ac.VolumeSpec().
+		WithVolumeClassRef(corev1.LocalObjectReference{Name: dataDisk.VolumeClassName}).
+		WithResources(corev1alpha1.ResourceList{corev1alpha1.ResourceStorage: dataDisk.Size})
+	if dataDisk.VolumePoolName != "" {
+		volumeSpec.WithVolumePoolRef(corev1.LocalObjectReference{Name: dataDisk.VolumePoolName})
+	}
+
+	return computev1alpha1ac.Volume().
+		WithName(dataDisk.Name).
+		WithDevice(apiv1alpha1.DataDiskDevice(index)).
 		WithEphemeral(computev1alpha1ac.EphemeralVolumeSource().
 			WithVolumeTemplate(storagev1alpha1ac.VolumeTemplateSpec().
-				WithSpec(storagev1alpha1ac.VolumeSpec().
-					WithVolumeClassRef(corev1.LocalObjectReference{Name: providerSpec.RootDisk.VolumeClassName}).
-					WithResources(corev1alpha1.ResourceList{corev1alpha1.ResourceStorage: providerSpec.RootDisk.Size}).
-					WithDataSource(storagev1alpha1ac.VolumeDataSource().
-						WithOSImage(storagev1alpha1ac.OSDataSource().
-							WithImage(providerSpec.Image),
-						),
-					),
-				),
+				WithLabels(labels).
+				WithLabels(dataDisk.Labels).
+				WithAnnotations(annotations).
+				WithSpec(withVolumeOptions(volumeSpec, dataDisk.EncryptionSecretName, dataDisk.Tolerations)),
 			),
-		),
-	}
+		)
 }
 
 // getIgnitionKeyOrDefault checks if key is empty otherwise return default ingintion key
@@ -242,18 +495,18 @@ func getIgnitionKeyOrDefault(key string) string {
 // validateProviderSpecAndSecret Validates providerSpec and provider secret
 func validateProviderSpecAndSecret(class *machinev1alpha1.MachineClass, secret *corev1.Secret) (*apiv1alpha1.ProviderSpec, error) {
 	if class == nil {
-		return nil, status.Error(codes.Internal, "MachineClass in ProviderSpec is not set")
+		return nil, status.Error(codes.InvalidArgument, "MachineClass in ProviderSpec is not set")
 	}
 
 	var providerSpec *apiv1alpha1.ProviderSpec
 	if err := json.Unmarshal(class.ProviderSpec.Raw, &providerSpec); err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to decode provider spec: %v", err))
 	}
 
 	validationErr := validation.ValidateProviderSpecAndSecret(providerSpec, secret, field.NewPath("providerSpec"))
 	if validationErr.ToAggregate() != nil && len(validationErr.ToAggregate().Errors()) > 0 {
-		err := fmt.Errorf("failed to validate provider spec and secret: %v", validationErr.ToAggregate().Errors())
-		return nil, status.Error(codes.Internal, err.Error())
+		// validation errors are permanent, so they must not be retried with a short period
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to validate provider spec and secret: %v", validationErr.ToAggregate().Errors()))
 	}
 
 	return providerSpec, nil
@@ -4,11 +4,18 @@
 package ironcore
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/netip"
+	"strings"
+	"time"
 
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
 	commonv1alpha1 "github.com/ironcore-dev/ironcore/api/common/v1alpha1"
 	computev1alpha1 "github.com/ironcore-dev/ironcore/api/compute/v1alpha1"
 	corev1alpha1 "github.com/ironcore-dev/ironcore/api/core/v1alpha1"
@@ -19,10 +26,13 @@ import (
 	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/ironcore/testing"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/vincent-petithory/dataurl"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	. "sigs.k8s.io/controller-runtime/pkg/envtest/komega"
 )
 
@@ -62,6 +72,9 @@ var _ = Describe("CreateMachine", func() {
 			HaveField("ObjectMeta.Labels", map[string]string{
 				ShootNameLabelKey:      "my-shoot",
 				ShootNamespaceLabelKey: "my-shoot-namespace",
+				MachineNameLabel:       machineName,
+				MachineClassLabel:      "",
+				MachineNamespaceLabel:  "",
 			}),
 			HaveField("Spec.MachineClassRef", corev1.LocalObjectReference{Name: "machine-class"}),
 			HaveField("Spec.MachinePoolRef", &corev1.LocalObjectReference{Name: "az1"}),
@@ -76,6 +89,9 @@ var _ = Describe("CreateMachine", func() {
 								Labels: map[string]string{
 									ShootNameLabelKey:      "my-shoot",
 									ShootNamespaceLabelKey: "my-shoot-namespace",
+									MachineNameLabel:       machineName,
+									MachineClassLabel:      "",
+									MachineNamespaceLabel:  "",
 								},
 							},
 							Spec: networkingv1alpha1.NetworkInterfaceSpec{
@@ -87,6 +103,15 @@ var _ = Describe("CreateMachine", func() {
 									{
 										Ephemeral: &networkingv1alpha1.EphemeralPrefixSource{
 											PrefixTemplate: &ipamv1alpha1.PrefixTemplateSpec{
+												ObjectMeta: metav1.ObjectMeta{
+													Labels: map[string]string{
+														ShootNameLabelKey:      "my-shoot",
+														ShootNamespaceLabelKey: "my-shoot-namespace",
+														MachineNameLabel:       machineName,
+														MachineClassLabel:      "",
+														MachineNamespaceLabel:  "",
+													},
+												},
 												Spec: ipamv1alpha1.PrefixSpec{
 													IPFamily:     corev1.IPv4Protocol,
 													PrefixLength: 32,
@@ -109,6 +134,15 @@ var _ = Describe("CreateMachine", func() {
 				VolumeSource: computev1alpha1.VolumeSource{
 					Ephemeral: &computev1alpha1.EphemeralVolumeSource{
 						VolumeTemplate: &storagev1alpha1.VolumeTemplateSpec{
+							ObjectMeta: metav1.ObjectMeta{
+								Labels: map[string]string{
+									ShootNameLabelKey:      "my-shoot",
+									ShootNamespaceLabelKey: "my-shoot-namespace",
+									MachineNameLabel:       machineName,
+									MachineClassLabel:      "",
+									MachineNamespaceLabel:  "",
+								},
+							},
 							Spec: storagev1alpha1.VolumeSpec{
 								VolumeClassRef: &corev1.LocalObjectReference{
 									Name: "foo",
@@ -143,6 +177,7 @@ var _ = Describe("CreateMachine", func() {
 		ignitionData, err := json.Marshal(testing.SampleIgnition)
 		Expect(err).NotTo(HaveOccurred())
 		Eventually(Object(ignition)).Should(SatisfyAll(
+			HaveField("ObjectMeta.Labels", HaveKeyWithValue(MachineNameLabel, machineName)),
 			HaveField("Data", HaveKeyWithValue("ignition.json", MatchJSON(ignitionData))),
 		))
 
@@ -205,4 +240,886 @@ var _ = Describe("CreateMachine", func() {
 			HaveField("Spec.Power", computev1alpha1.PowerOn),
 		))
 	})
+
+	It("should create a machine with cloud-config bootstrap data", func(ctx SpecContext) {
+		By("creating machine with bootstrap format CloudConfig")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["bootstrapFormat"] = v1alpha1.BootstrapFormatCloudConfig
+		delete(providerSpec, "ignition")
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the secret contains the cloud-config")
+		ignition := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(ignition)).Should(
+			HaveField("Data", HaveKeyWithValue("ignition.json", BeEquivalentTo(testing.SampleCloudConfig))),
+		)
+
+		By("failing if ignition is set together with bootstrap format CloudConfig")
+		providerSpec["ignition"] = testing.SampleProviderSpec["ignition"]
+		_, err := (*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})
+		Expect(err).To(MatchError(ContainSubstring("ignition is only supported with bootstrapFormat Ignition")))
+	})
+
+	It("should compress large ignition content and reject ignitions exceeding the secret size", func(ctx SpecContext) {
+		By("creating machine with large user data")
+		largeSecret := providerSecret.DeepCopy()
+		largeSecret.Data = map[string][]byte{
+			"userData": []byte(strings.Repeat("echo hello world\n", 4096)),
+		}
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       largeSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the init script is stored compressed")
+		ignition := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(ignition)).Should(
+			HaveField("Data", HaveKeyWithValue("ignition.json", ContainSubstring(`"compression":"gzip"`))),
+		)
+
+		By("failing if the ignition exceeds the maximum secret size")
+		userData := make([]byte, corev1.MaxSecretSize)
+		_, err := rand.Read(userData)
+		Expect(err).NotTo(HaveOccurred())
+		largeSecret.Data["userData"] = []byte(base64.StdEncoding.EncodeToString(userData))
+		_, err = (*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", 1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       largeSecret,
+		})
+		statusErr, ok := status.FromError(err)
+		Expect(ok).To(BeTrue())
+		Expect(statusErr.Code()).To(Equal(codes.InvalidArgument))
+		Expect(statusErr.Message()).To(ContainSubstring("exceeds the maximum secret size"))
+	})
+
+	It("should report ignition warnings as events and fail on warnings in strict mode", func(ctx SpecContext) {
+		By("creating machine with an ignition containing an unknown key")
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the warning has been recorded as event on the machine")
+		Eventually(func(g Gomega) {
+			events := &corev1.EventList{}
+			g.Expect(k8sClient.List(ctx, events, client.InNamespace(ns.Name))).To(Succeed())
+			g.Expect(events.Items).To(ContainElement(SatisfyAll(
+				HaveField("InvolvedObject.Name", "machine-0"),
+				HaveField("Type", corev1.EventTypeWarning),
+				HaveField("Reason", "IgnitionWarning"),
+				HaveField("Message", ContainSubstring("unused key sshAuthorizedKeys")),
+			)))
+		}).Should(Succeed())
+
+		By("creating the machine again")
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       providerSecret,
+		})).NotTo(BeNil())
+
+		By("ensuring that the count of the existing event has been increased instead of recording a second event")
+		Eventually(func(g Gomega) {
+			events := &corev1.EventList{}
+			g.Expect(k8sClient.List(ctx, events, client.InNamespace(ns.Name))).To(Succeed())
+			g.Expect(events.Items).To(ConsistOf(SatisfyAll(
+				HaveField("InvolvedObject.Name", "machine-0"),
+				HaveField("Reason", "IgnitionWarning"),
+				HaveField("Count", BeEquivalentTo(2)),
+			)))
+		}).Should(Succeed())
+
+		By("failing if ignition strict mode is enabled")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["ignitionStrict"] = true
+		_, err := (*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", 1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})
+		Expect(err).To(MatchError(ContainSubstring("ignition has warnings in strict mode")))
+	})
+
+	It("should render ssh authorized keys, users and ca certificates into the ignition", func(ctx SpecContext) {
+		By("creating machine with ssh authorized keys, users and ca certificates")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["sshAuthorizedKeys"] = []string{testing.SampleSSHAuthorizedKey}
+		providerSpec["users"] = []v1alpha1.User{{
+			Name:              "foo",
+			Groups:            []string{"wheel"},
+			SSHAuthorizedKeys: []string{testing.SampleSSHAuthorizedKey},
+			Shell:             "/bin/bash",
+		}}
+		providerSpec["caCertificates"] = []string{testing.SampleCACertificate}
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the ignition contains the users and ca certificates")
+		ignitionSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(ignitionSecret)).Should(HaveField("Data", HaveKey("ignition.json")))
+		ignitionConfig := map[string]interface{}{}
+		Expect(json.Unmarshal(ignitionSecret.Data["ignition.json"], &ignitionConfig)).To(Succeed())
+		Expect(ignitionConfig).To(HaveKeyWithValue("passwd", HaveKeyWithValue("users", ContainElements(
+			map[string]interface{}{
+				"name":              "core",
+				"sshAuthorizedKeys": []interface{}{testing.SampleSSHAuthorizedKey},
+			},
+			map[string]interface{}{
+				"name":              "foo",
+				"groups":            []interface{}{"wheel"},
+				"sshAuthorizedKeys": []interface{}{testing.SampleSSHAuthorizedKey},
+				"shell":             "/bin/bash",
+			},
+		))))
+		Expect(ignitionConfig).To(HaveKeyWithValue("storage", HaveKeyWithValue("files", ContainElement(
+			HaveKeyWithValue("path", "/usr/local/share/ca-certificates/ironcore-ca-0.crt"),
+		))))
+		Expect(ignitionConfig).To(HaveKeyWithValue("systemd", HaveKeyWithValue("units", ContainElement(
+			HaveKeyWithValue("name", "ironcore-ca-certificates.service"),
+		))))
+	})
+
+	It("should render ntp, proxy and sysctl settings into the ignition", func(ctx SpecContext) {
+		By("creating machine with ntp, proxy and sysctl settings")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["ntp"] = v1alpha1.NTP{Servers: []string{"ntp.example.com", "10.0.0.1"}}
+		providerSpec["proxy"] = v1alpha1.Proxy{
+			HTTPProxy:  "http://proxy.example.com:3128",
+			HTTPSProxy: "http://proxy.example.com:3128",
+			NoProxy:    []string{"localhost", "10.0.0.0/8"},
+		}
+		providerSpec["sysctls"] = map[string]string{"net.ipv4.ip_forward": "1"}
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the ignition contains the host settings")
+		ignitionSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(ignitionSecret)).Should(HaveField("Data", HaveKey("ignition.json")))
+		ignitionConfig := map[string]interface{}{}
+		Expect(json.Unmarshal(ignitionSecret.Data["ignition.json"], &ignitionConfig)).To(Succeed())
+		Expect(ignitionConfig).To(HaveKeyWithValue("storage", HaveKeyWithValue("files", ContainElements(
+			SatisfyAll(
+				HaveKeyWithValue("path", "/etc/systemd/timesyncd.conf.d/ntp.conf"),
+				HaveKeyWithValue("contents", HaveKeyWithValue("source", ContainSubstring("ntp.example.com"))),
+			),
+			SatisfyAll(
+				HaveKeyWithValue("path", "/etc/systemd/system.conf.d/proxy.conf"),
+				HaveKeyWithValue("contents", HaveKeyWithValue("source", ContainSubstring("HTTPS_PROXY"))),
+			),
+			SatisfyAll(
+				HaveKeyWithValue("path", "/etc/sysctl.d/99-ironcore.conf"),
+				HaveKeyWithValue("contents", HaveKeyWithValue("source", ContainSubstring("net.ipv4.ip_forward"))),
+			),
+		))))
+	})
+
+	It("should write the host settings literally instead of rendering them as template", func(ctx SpecContext) {
+		By("creating machine with a sysctl value containing a template action")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["sysctls"] = map[string]string{"kernel.domainname": "{{ .UserData }}"}
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the sysctl value has been written literally")
+		ignitionSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(ignitionSecret)).Should(HaveField("Data", HaveKey("ignition.json")))
+		ignitionConfig := map[string]interface{}{}
+		Expect(json.Unmarshal(ignitionSecret.Data["ignition.json"], &ignitionConfig)).To(Succeed())
+		Expect(ignitionConfig).To(HaveKeyWithValue("storage", HaveKeyWithValue("files", ContainElement(SatisfyAll(
+			HaveKeyWithValue("path", "/etc/sysctl.d/99-ironcore.conf"),
+			HaveKeyWithValue("contents", HaveKeyWithValue("source", WithTransform(func(source string) (string, error) {
+				data, err := dataurl.DecodeString(source)
+				if err != nil {
+					return "", err
+				}
+				return string(data.Data), nil
+			}, Equal("kernel.domainname = {{ .UserData }}\n")))),
+		)))))
+	})
+
+	It("should render the dns configuration into the ignition", func(ctx SpecContext) {
+		By("creating machine with global and per-interface dns configuration")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["dns"] = v1alpha1.DNS{
+			Servers:       []netip.Addr{netip.MustParseAddr("10.0.0.1")},
+			SearchDomains: []string{"example.com"},
+			DNSSEC:        v1alpha1.DNSSECModeAllowDowngrade,
+			Interfaces: []v1alpha1.InterfaceDNS{{
+				Name:          "eth1",
+				Servers:       []netip.Addr{netip.MustParseAddr("192.168.0.1")},
+				SearchDomains: []string{"~corp.example.com"},
+			}},
+		}
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the ignition contains the resolved and networkd configuration")
+		ignitionSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(ignitionSecret)).Should(HaveField("Data", HaveKey("ignition.json")))
+		ignitionConfig := map[string]interface{}{}
+		Expect(json.Unmarshal(ignitionSecret.Data["ignition.json"], &ignitionConfig)).To(Succeed())
+		Expect(ignitionConfig).To(HaveKeyWithValue("storage", HaveKeyWithValue("files", ContainElements(
+			SatisfyAll(
+				HaveKeyWithValue("path", "/etc/systemd/resolved.conf.d/dns.conf"),
+				HaveKeyWithValue("contents", HaveKeyWithValue("source", SatisfyAll(
+					ContainSubstring("DNS%3D10.0.0.1"),
+					ContainSubstring("Domains%3Dexample.com"),
+					ContainSubstring("DNSSEC%3Dallow-downgrade"),
+				))),
+			),
+			SatisfyAll(
+				HaveKeyWithValue("path", "/etc/systemd/network/10-ironcore-eth1.network"),
+				HaveKeyWithValue("contents", HaveKeyWithValue("source", SatisfyAll(
+					ContainSubstring("Name%3Deth1"),
+					ContainSubstring("DNS%3D192.168.0.1"),
+					ContainSubstring("Domains%3D~corp.example.com"),
+				))),
+			),
+		))))
+	})
+
+	It("should render the network interface configuration into the ignition", func(ctx SpecContext) {
+		By("creating machine with mtu, routes and vlans for the primary network interface")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["networkInterfaces"] = []v1alpha1.NetworkInterface{{
+			Name:              v1alpha1.PrimaryNetworkInterfaceName,
+			HostInterfaceName: "eth0",
+			MTU:               ptr.To[int32](1450),
+			Routes: []v1alpha1.Route{{
+				Destination: netip.MustParsePrefix("10.1.0.0/16"),
+				Gateway:     ptr.To(netip.MustParseAddr("10.0.0.1")),
+			}},
+			VLANs: []v1alpha1.VLAN{{
+				ID:        100,
+				Addresses: []netip.Prefix{netip.MustParsePrefix("172.16.0.2/24")},
+			}},
+		}}
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the ignition contains the systemd-networkd configuration")
+		ignitionSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(ignitionSecret)).Should(HaveField("Data", HaveKey("ignition.json")))
+		ignitionConfig := map[string]interface{}{}
+		Expect(json.Unmarshal(ignitionSecret.Data["ignition.json"], &ignitionConfig)).To(Succeed())
+		Expect(ignitionConfig).To(HaveKeyWithValue("storage", HaveKeyWithValue("files", ContainElements(
+			SatisfyAll(
+				HaveKeyWithValue("path", "/etc/systemd/network/10-ironcore-eth0.network"),
+				HaveKeyWithValue("contents", HaveKeyWithValue("source", SatisfyAll(
+					ContainSubstring("MTUBytes%3D1450"),
+					ContainSubstring("VLAN%3Dvlan100"),
+					ContainSubstring("Gateway%3D10.0.0.1"),
+				))),
+			),
+			SatisfyAll(
+				HaveKeyWithValue("path", "/etc/systemd/network/10-ironcore-vlan100.netdev"),
+				HaveKeyWithValue("contents", HaveKeyWithValue("source", ContainSubstring("Id%3D100"))),
+			),
+			SatisfyAll(
+				HaveKeyWithValue("path", "/etc/systemd/network/10-ironcore-vlan100.network"),
+				HaveKeyWithValue("contents", HaveKeyWithValue("source", ContainSubstring("Address%3D172.16.0.2%2F24"))),
+			),
+		))))
+	})
+
+	It("should replace the network configuration of the image for the configured interfaces", func(ctx SpecContext) {
+		By("creating machine with only the mtu of the primary network interface")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["networkInterfaces"] = []v1alpha1.NetworkInterface{{
+			Name:              v1alpha1.PrimaryNetworkInterfaceName,
+			HostInterfaceName: "eth0",
+			MTU:               ptr.To[int32](1450),
+		}}
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the unit configures the interface via DHCP together with the mtu")
+		ignitionSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(ignitionSecret)).Should(HaveField("Data", HaveKey("ignition.json")))
+		ignitionConfig := map[string]interface{}{}
+		Expect(json.Unmarshal(ignitionSecret.Data["ignition.json"], &ignitionConfig)).To(Succeed())
+		Expect(ignitionConfig).To(HaveKeyWithValue("storage", HaveKeyWithValue("files", ContainElement(SatisfyAll(
+			HaveKeyWithValue("path", "/etc/systemd/network/10-ironcore-eth0.network"),
+			HaveKeyWithValue("contents", HaveKeyWithValue("source", WithTransform(func(source string) (string, error) {
+				data, err := dataurl.DecodeString(source)
+				if err != nil {
+					return "", err
+				}
+				return string(data.Data), nil
+			}, Equal("[Match]\nName=eth0\n\n[Link]\nMTUBytes=1450\n\n[Network]\nDHCP=yes\n")))),
+		)))))
+	})
+
+	It("should create a machine with data disks, filesystems and kernel arguments", func(ctx SpecContext) {
+		By("creating machine with a data disk, a filesystem and kernel arguments")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["dataDisks"] = []v1alpha1.DataDisk{{
+			Name:            "data",
+			Size:            resource.MustParse("20Gi"),
+			VolumeClassName: "foo",
+		}}
+		providerSpec["filesystems"] = []v1alpha1.Filesystem{{
+			VolumeName: "data",
+			Format:     "xfs",
+			Path:       "/var/lib/data",
+		}}
+		providerSpec["kernelArguments"] = []string{"hugepages=1024"}
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the ironcore machine has the data disk attached")
+		machine := &computev1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(machine)).Should(HaveField("Spec.Volumes", ContainElement(SatisfyAll(
+			HaveField("Name", "data"),
+			HaveField("Device", ptr.To("odb")),
+			HaveField("VolumeSource.Ephemeral.VolumeTemplate.Spec", SatisfyAll(
+				HaveField("VolumeClassRef", &corev1.LocalObjectReference{Name: "foo"}),
+				HaveField("Resources", corev1alpha1.ResourceList{
+					corev1alpha1.ResourceStorage: resource.MustParse("20Gi"),
+				}),
+			)),
+		))))
+
+		By("ensuring that the ignition contains the filesystem and kernel arguments")
+		ignitionSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(ignitionSecret)).Should(HaveField("Data", HaveKey("ignition.json")))
+		ignitionConfig := map[string]interface{}{}
+		Expect(json.Unmarshal(ignitionSecret.Data["ignition.json"], &ignitionConfig)).To(Succeed())
+		Expect(ignitionConfig).To(HaveKeyWithValue("kernelArguments", HaveKeyWithValue("shouldExist", ConsistOf("hugepages=1024"))))
+		Expect(ignitionConfig).To(HaveKeyWithValue("storage", HaveKeyWithValue("filesystems", ContainElement(SatisfyAll(
+			HaveKeyWithValue("device", "/dev/disk/by-id/virtio-odb"),
+			HaveKeyWithValue("format", "xfs"),
+			HaveKeyWithValue("path", "/var/lib/data"),
+		)))))
+
+		By("failing if a filesystem does not reference a data disk")
+		providerSpec["filesystems"] = []v1alpha1.Filesystem{{
+			VolumeName: "root",
+			Format:     "xfs",
+		}}
+		_, err := (*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})
+		Expect(err).To(MatchError(ContainSubstring("volumeName must reference a data disk")))
+	})
+
+	It("should create a machine with a root disk restored from a volume snapshot", func(ctx SpecContext) {
+		By("creating machine with a root disk volume snapshot")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		delete(providerSpec, "image")
+		providerSpec["rootDisk"] = map[string]string{
+			"volumeClassName":    "foo",
+			"size":               "10Gi",
+			"volumeSnapshotName": "golden",
+		}
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the root disk is restored from the volume snapshot")
+		machine := &computev1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(machine)).Should(HaveField("Spec.Volumes", ContainElement(SatisfyAll(
+			HaveField("Name", v1alpha1.RootDiskVolumeName),
+			HaveField("VolumeSource.Ephemeral.VolumeTemplate.Spec.DataSource", SatisfyAll(
+				HaveField("VolumeSnapshotRef", &corev1.LocalObjectReference{Name: "golden"}),
+				HaveField("OSImage", BeNil()),
+			)),
+		))))
+
+		By("failing if the image is set together with the volume snapshot")
+		providerSpec["image"] = testing.SampleProviderSpec["image"]
+		_, err := (*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})
+		Expect(err).To(MatchError(ContainSubstring("image must not be set")))
+	})
+
+	It("should create a machine with encrypted disks, volume labels and tolerations", func(ctx SpecContext) {
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["rootDisk"] = v1alpha1.RootDisk{
+			Size:                 resource.MustParse("10Gi"),
+			VolumeClassName:      "foo",
+			EncryptionSecretName: "encryption-key",
+			Labels:               map[string]string{"tier": "fast"},
+			Tolerations: []v1alpha1.Toleration{{
+				Key:      "tier",
+				Operator: v1alpha1.TolerationOpEqual,
+				Value:    "fast",
+				Effect:   v1alpha1.TaintEffectNoSchedule,
+			}},
+		}
+		providerSpec["dataDisks"] = []v1alpha1.DataDisk{{
+			Name:                 "data",
+			Size:                 resource.MustParse("20Gi"),
+			VolumeClassName:      "foo",
+			EncryptionSecretName: "encryption-key",
+		}}
+
+		By("failing if the encryption secret does not exist")
+		_, err := (*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})
+		Expect(err).To(MatchError(ContainSubstring("encryption secret %s/encryption-key does not exist", ns.Name)))
+
+		By("creating the encryption secret")
+		encryptionSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "encryption-key",
+			},
+			Data: map[string][]byte{"encryptionKey": []byte("foo")},
+		}
+		Expect(k8sClient.Create(ctx, encryptionSecret)).To(Succeed())
+
+		By("creating machine with encrypted disks")
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the volume options are propagated to the volume templates")
+		machine := &computev1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(machine)).Should(HaveField("Spec.Volumes", ContainElements(
+			SatisfyAll(
+				HaveField("Name", v1alpha1.RootDiskVolumeName),
+				HaveField("VolumeSource.Ephemeral.VolumeTemplate.Labels", HaveKeyWithValue("tier", "fast")),
+				HaveField("VolumeSource.Ephemeral.VolumeTemplate.Spec", SatisfyAll(
+					HaveField("Encryption.SecretRef", corev1.LocalObjectReference{Name: "encryption-key"}),
+					HaveField("Tolerations", ConsistOf(commonv1alpha1.Toleration{
+						Key:      "tier",
+						Operator: commonv1alpha1.TolerationOpEqual,
+						Value:    "fast",
+						Effect:   commonv1alpha1.TaintEffectNoSchedule,
+					})),
+				)),
+			),
+			SatisfyAll(
+				HaveField("Name", "data"),
+				HaveField("VolumeSource.Ephemeral.VolumeTemplate.Spec.Encryption.SecretRef", corev1.LocalObjectReference{Name: "encryption-key"}),
+			),
+		)))
+	})
+
+	It("should set the labels and annotations on every created object", func(ctx SpecContext) {
+		By("creating machine with annotations and a data disk")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["annotations"] = map[string]string{"cost-center": "1234"}
+		providerSpec["dataDisks"] = []v1alpha1.DataDisk{{
+			Name:            "data",
+			Size:            resource.MustParse("20Gi"),
+			VolumeClassName: "foo",
+		}}
+		machineClass := newMachineClass(v1alpha1.ProviderName, providerSpec)
+		machineClass.Name = "my-machine-class"
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: machineClass,
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		haveLabelsAndAnnotations := SatisfyAll(
+			HaveField("Labels", SatisfyAll(
+				HaveKeyWithValue(ShootNameLabelKey, "my-shoot"),
+				HaveKeyWithValue(MachineNameLabel, "machine-0"),
+				HaveKeyWithValue(MachineClassLabel, "my-machine-class"),
+			)),
+			HaveField("Annotations", HaveKeyWithValue("cost-center", "1234")),
+		)
+
+		By("ensuring that the machine and its templates have the labels and annotations")
+		machine := &computev1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(machine)).Should(SatisfyAll(
+			HaveField("ObjectMeta", haveLabelsAndAnnotations),
+			HaveField("Spec.NetworkInterfaces", ConsistOf(SatisfyAll(
+				HaveField("Ephemeral.NetworkInterfaceTemplate.ObjectMeta", haveLabelsAndAnnotations),
+				HaveField("Ephemeral.NetworkInterfaceTemplate.Spec.IPs", ConsistOf(
+					HaveField("Ephemeral.PrefixTemplate.ObjectMeta", haveLabelsAndAnnotations),
+				)),
+			))),
+			HaveField("Spec.Volumes", ConsistOf(
+				HaveField("Ephemeral.VolumeTemplate.ObjectMeta", haveLabelsAndAnnotations),
+				HaveField("Ephemeral.VolumeTemplate.ObjectMeta", haveLabelsAndAnnotations),
+			)),
+		))
+
+		By("ensuring that the ignition secret has the labels and annotations")
+		ignitionSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(ignitionSecret)).Should(HaveField("ObjectMeta", haveLabelsAndAnnotations))
+	})
+
+	It("should create the machine in the ironcore cluster and namespace selected by the machine class secret", func(ctx SpecContext) {
+		By("creating a second ironcore namespace")
+		otherNamespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "other-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, otherNamespace)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, otherNamespace)
+
+		By("creating machine with a kubeconfig for the second namespace in the machine class secret")
+		secret := providerSecret.DeepCopy()
+		secret.Data[v1alpha1.SecretKubeconfigKey] = newKubeconfig(cfg, otherNamespace.Name)
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       secret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, otherNamespace.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the ironcore machine and its ignition have been created in the second namespace")
+		Eventually(Get(&computev1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: otherNamespace.Name,
+				Name:      "machine-0",
+			},
+		})).Should(Succeed())
+		Eventually(Get(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: otherNamespace.Name,
+				Name:      "machine-0",
+			},
+		})).Should(Succeed())
+
+		By("ensuring that the namespace of the machine class secret overrides the namespace of the kubeconfig")
+		secret.Data[v1alpha1.SecretNamespaceKey] = []byte(ns.Name)
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", 1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       secret,
+		})).To(HaveField("ProviderID", fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 1)))
+
+		By("failing if the kubeconfig of the machine class secret is invalid")
+		secret.Data[v1alpha1.SecretKubeconfigKey] = []byte("foo")
+		_, err := (*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", 2, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       secret,
+		})
+		Expect(err).To(MatchError(ContainSubstring("kubeconfig is invalid")))
+	})
+
+	It("should render the bootstrap retry and reporting settings into the bootstrap script", func(ctx SpecContext) {
+		By("creating machine with bootstrap settings")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["bootstrapFormat"] = v1alpha1.BootstrapFormatCloudConfig
+		delete(providerSpec, "ignition")
+		providerSpec["bootstrap"] = v1alpha1.Bootstrap{
+			MaxRetries:    ptr.To[int32](3),
+			RetryInterval: &metav1.Duration{Duration: 30 * time.Second},
+			Timeout:       &metav1.Duration{Duration: 10 * time.Minute},
+			CallbackURL:   "https://bootstrap.example.com/status",
+		}
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the bootstrap script contains the settings")
+		ignitionSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(ignitionSecret)).Should(HaveField("Data", HaveKey("ignition.json")))
+		Expect(string(ignitionSecret.Data["ignition.json"])).To(SatisfyAll(
+			ContainSubstring("- /var/lib/ironcore-cloud-config/run.sh"),
+			ContainSubstring("max_attempts=4"),
+			ContainSubstring("retry_interval=30"),
+			ContainSubstring("attempt_timeout=600"),
+			ContainSubstring("callback_url='https://bootstrap.example.com/status'"),
+		))
+	})
+
+	It("should retry the bootstrap script until it succeeds if no max retries are set", func(ctx SpecContext) {
+		By("creating machine without bootstrap settings")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["bootstrapFormat"] = v1alpha1.BootstrapFormatCloudConfig
+		delete(providerSpec, "ignition")
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the bootstrap script retries without limit")
+		ignitionSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(ignitionSecret)).Should(HaveField("Data", HaveKey("ignition.json")))
+		Expect(string(ignitionSecret.Data["ignition.json"])).To(SatisfyAll(
+			ContainSubstring("max_attempts=0"),
+			ContainSubstring("retry_interval=5"),
+			ContainSubstring("attempt_timeout=0"),
+		))
+	})
+
+	It("should refuse to create a machine whose name collides with the ironcore machine of another machine", func(ctx SpecContext) {
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["naming"] = map[string]interface{}{
+			"template": "{{ slice .Name 0 7 }}",
+		}
+		machineClass := newMachineClass(v1alpha1.ProviderName, providerSpec)
+
+		By("creating a machine")
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: machineClass,
+			Secret:       providerSecret,
+		})).To(HaveField("NodeName", "machine"))
+
+		By("failing to create another machine with the same ironcore machine name")
+		Eventually(func(g Gomega) {
+			_, err := (*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+				Machine:      newMachine(ns, "machine", 1, nil),
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})
+			g.Expect(err).To(MatchError(status.Error(codes.AlreadyExists, "ironcore machine machine of machine machine-1 already exists for machine machine-0")))
+		}).Should(Succeed())
+
+		By("ensuring that the ironcore machine still belongs to the first machine")
+		machine := &computev1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine",
+			},
+		}
+		Consistently(Object(machine)).Should(HaveField("ObjectMeta.Annotations", HaveKeyWithValue(MachineNameAnnotation, "machine-0")))
+
+		By("creating the first machine again")
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: machineClass,
+			Secret:       providerSecret,
+		})).To(HaveField("NodeName", "machine"))
+	})
+
+	It("should name the ironcore objects according to the naming of the provider spec", func(ctx SpecContext) {
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["naming"] = map[string]interface{}{
+			"prefix":   "mcm-",
+			"template": "{{ .ShootName }}-{{ .Name }}",
+		}
+		machineClass := newMachineClass(v1alpha1.ProviderName, providerSpec)
+		providerID := fmt.Sprintf("%s://%s/mcm-my-shoot-machine-0", v1alpha1.ProviderName, ns.Name)
+
+		By("creating a machine")
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: machineClass,
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: providerID,
+			NodeName:   "mcm-my-shoot-machine-0",
+		}))
+
+		By("ensuring that the ironcore machine records the machine name")
+		machine := &computev1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "mcm-my-shoot-machine-0",
+			},
+		}
+		Eventually(Object(machine)).Should(SatisfyAll(
+			HaveField("ObjectMeta.Annotations", HaveKeyWithValue(MachineNameAnnotation, "machine-0")),
+			HaveField("ObjectMeta.Labels", HaveKeyWithValue(MachineNameLabel, "machine-0")),
+			HaveField("Spec.IgnitionRef.Name", machine.Name),
+		))
+		ignitionSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      machine.Name,
+			},
+		}
+		Eventually(Get(ignitionSecret)).Should(Succeed())
+
+		By("ensuring the status of a machine without provider ID")
+		Expect((*drv).GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: machineClass,
+			Secret:       providerSecret,
+		})).To(Equal(&driver.GetMachineStatusResponse{
+			ProviderID: providerID,
+			NodeName:   machine.Name,
+		}))
+
+		By("ensuring that the machine is listed with its machine name")
+		Expect((*drv).ListMachines(ctx, &driver.ListMachinesRequest{
+			MachineClass: machineClass,
+			Secret:       providerSecret,
+		})).To(HaveField("MachineList", Equal(map[string]string{providerID: "machine-0"})))
+
+		By("deleting the machine")
+		Expect((*drv).DeleteMachine(ctx, &driver.DeleteMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: machineClass,
+			Secret:       providerSecret,
+		})).To(Equal(&driver.DeleteMachineResponse{}))
+		Eventually(Get(machine)).Should(Satisfy(apierrors.IsNotFound))
+		Eventually(Get(ignitionSecret)).Should(Satisfy(apierrors.IsNotFound))
+	})
 })
@@ -5,9 +5,11 @@ package ironcore
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
+	machinev1alpha1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
@@ -20,9 +22,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	computev1alpha1 "github.com/ironcore-dev/ironcore/api/compute/v1alpha1"
+	networkingv1alpha1 "github.com/ironcore-dev/ironcore/api/networking/v1alpha1"
+	storagev1alpha1 "github.com/ironcore-dev/ironcore/api/storage/v1alpha1"
 )
 
-// DeleteMachine handles a machine deletion request and also deletes ignitionSecret associated with it
+// DeleteMachine handles a machine deletion request and also deletes ignitionSecret associated with it. The deletion
+// is idempotent, leftovers of a machine which is already gone are cleaned up.
 func (d *ironcoreDriver) DeleteMachine(ctx context.Context, req *driver.DeleteMachineRequest) (*driver.DeleteMachineResponse, error) {
 	if isEmptyDeleteRequest(req) {
 		return nil, status.Error(codes.InvalidArgument, "received empty request")
@@ -34,51 +39,132 @@ func (d *ironcoreDriver) DeleteMachine(ctx context.Context, req *driver.DeleteMa
 	klog.V(3).Infof("Machine deletion request has been received for %q", req.Machine.Name)
 	defer klog.V(3).Infof("Machine deletion request has been processed for %q", req.Machine.Name)
 
+	providerSpec := &apiv1alpha1.ProviderSpec{}
+	if err := json.Unmarshal(req.MachineClass.ProviderSpec.Raw, providerSpec); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to decode provider spec: %v", err))
+	}
+
+	d, err := d.forSecret(req.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	ironcoreMachineKey, err := d.getIroncoreMachineKey(req.Machine, providerSpec)
+	if err != nil {
+		return nil, err
+	}
+	// the objects of the machine live in the namespace of the ironcore Machine
+	d = d.withNamespace(ironcoreMachineKey.Namespace)
+
 	ignitionSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      d.getIgnitionNameForMachine(ctx, req.Machine.Name),
+			Name:      d.getIgnitionNameForMachine(ctx, ironcoreMachineKey.Name),
 			Namespace: d.IroncoreNamespace,
 		},
 	}
 
 	if err := d.IroncoreClient.Delete(ctx, ignitionSecret); client.IgnoreNotFound(err) != nil {
-		// Unknown leads to short retry in machine controller
-		return nil, status.Error(codes.Unknown, fmt.Sprintf("error deleting ignition secret: %s", err.Error()))
+		return nil, apiError(err, "error deleting ignition secret")
 	}
 
 	ironcoreMachine := &computev1alpha1.Machine{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      req.Machine.Name,
-			Namespace: d.IroncoreNamespace,
+			Name:      ironcoreMachineKey.Name,
+			Namespace: ironcoreMachineKey.Namespace,
 		},
 	}
 
+	// a machine which cannot be deleted right away keeps being listed for its machine class
+	if err := d.IroncoreClient.Get(ctx, ironcoreMachineKey, ironcoreMachine); err == nil {
+		if err := d.ensureProviderLabels(ctx, ironcoreMachine, req.Machine, req.MachineClass); err != nil {
+			return nil, err
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return nil, apiError(err, "failed to get ironcore machine %s", ironcoreMachineKey)
+	}
+
+	// a machine which is already gone, e.g. after a crash during a previous deletion, is not an error: the leftovers
+	// are cleaned up and the deletion succeeds
 	if err := d.IroncoreClient.Delete(ctx, ironcoreMachine); err != nil {
 		if !apierrors.IsNotFound(err) {
-			// Unknown leads to short retry in machine controller
-			return nil, status.Error(codes.Unknown, fmt.Sprintf("error deleting pod: %s", err.Error()))
+			return nil, apiError(err, "error deleting ironcore machine")
 		}
-		return nil, status.Error(codes.NotFound, err.Error())
+		klog.V(3).Infof("IronCore machine %s is already gone, cleaning up leftovers", client.ObjectKeyFromObject(ironcoreMachine))
+	} else if err := d.waitForMachineDeletion(ctx, ironcoreMachine); err != nil {
+		return nil, err
+	}
+
+	if err := d.deletePersistentRootVolume(ctx, ironcoreMachineKey.Name, providerSpec); err != nil {
+		return nil, err
+	}
+
+	if err := d.deleteNetworkInterfaces(ctx, req.Machine.Name, req.MachineClass); err != nil {
+		return nil, err
 	}
 
-	// Actively wait until the ironcore machine is deleted since the extension contract in machine-controller-manager expects drivers to
-	// do so. If we would not wait until the ironcore machine is gone it might happen that the kubelet could re-register the Node
-	// object even after it was already deleted by machine-controller-manager.
+	return &driver.DeleteMachineResponse{}, nil
+}
+
+// waitForMachineDeletion actively waits until the ironcore machine is deleted since the extension contract in
+// machine-controller-manager expects drivers to do so. If we would not wait until the ironcore machine is gone it might
+// happen that the kubelet could re-register the Node object even after it was already deleted by
+// machine-controller-manager.
+func (d *ironcoreDriver) waitForMachineDeletion(ctx context.Context, ironcoreMachine *computev1alpha1.Machine) error {
 	if err := wait.PollUntilContextTimeout(ctx, 5*time.Second, 10*time.Minute, true, func(ctx context.Context) (bool, error) {
 		if err := d.IroncoreClient.Get(ctx, client.ObjectKeyFromObject(ironcoreMachine), ironcoreMachine); err != nil {
 			if apierrors.IsNotFound(err) {
 				return true, nil
 			}
-			// Unknown leads to short retry in machine controller
-			return false, status.Error(codes.Unknown, err.Error())
+			return false, apiError(err, "failed to get ironcore machine")
 		}
 		return false, nil
 	}); err != nil {
-		// will be retried with short retry by machine controller
-		return nil, status.Error(codes.DeadlineExceeded, err.Error())
+		// a timeout is retried with short retry by machine controller
+		return apiError(err, "failed to wait for deletion of ironcore machine")
 	}
+	return nil
+}
 
-	return &driver.DeleteMachineResponse{}, nil
+// deletePersistentRootVolume deletes the persistent root disk Volume of the machine unless its reclaim policy
+// retains it. It must only be called once the ironcore Machine is gone, as the Volume is still in use before.
+func (d *ironcoreDriver) deletePersistentRootVolume(ctx context.Context, machineName string, providerSpec *apiv1alpha1.ProviderSpec) error {
+	if providerSpec.RootDisk == nil || !providerSpec.RootDisk.Persistent {
+		return nil
+	}
+	if providerSpec.RootDisk.ReclaimPolicy == apiv1alpha1.ReclaimPolicyRetain {
+		klog.V(3).Infof("Retaining persistent root volume %s of machine %s", getRootVolumeNameForMachine(machineName), machineName)
+		return nil
+	}
+
+	volume := &storagev1alpha1.Volume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getRootVolumeNameForMachine(machineName),
+			Namespace: d.IroncoreNamespace,
+		},
+	}
+	if err := d.IroncoreClient.Delete(ctx, volume); client.IgnoreNotFound(err) != nil {
+		return apiError(err, "error deleting persistent root volume")
+	}
+
+	return nil
+}
+
+// deleteNetworkInterfaces deletes the network interfaces which carry the provider labels of the machine. The network
+// interfaces of a machine are ephemeral, but may be left behind, e.g. if the machine was removed forcefully.
+func (d *ironcoreDriver) deleteNetworkInterfaces(ctx context.Context, machineName string, machineClass *machinev1alpha1.MachineClass) error {
+	nicList := &networkingv1alpha1.NetworkInterfaceList{}
+	if err := d.IroncoreClient.List(ctx, nicList, client.InNamespace(d.IroncoreNamespace), client.MatchingLabels(getObjectLabels(machineName, machineClass, nil))); err != nil {
+		return apiError(err, "failed to list network interfaces of machine %s", machineName)
+	}
+
+	for _, nic := range nicList.Items {
+		if err := d.IroncoreClient.Delete(ctx, &nic); client.IgnoreNotFound(err) != nil {
+			return apiError(err, "error deleting network interface %s", nic.Name)
+		}
+		klog.V(3).Infof("Deleted leftover network interface %s of machine %s", nic.Name, machineName)
+	}
+
+	return nil
 }
 
 func isEmptyDeleteRequest(req *driver.DeleteMachineRequest) bool {
@@ -7,7 +7,10 @@ import (
 	"fmt"
 
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
+	commonv1alpha1 "github.com/ironcore-dev/ironcore/api/common/v1alpha1"
 	computev1alpha1 "github.com/ironcore-dev/ironcore/api/compute/v1alpha1"
+	networkingv1alpha1 "github.com/ironcore-dev/ironcore/api/networking/v1alpha1"
+	storagev1alpha1 "github.com/ironcore-dev/ironcore/api/storage/v1alpha1"
 	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
 	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/ironcore/testing"
 	. "github.com/onsi/ginkgo/v2"
@@ -15,6 +18,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	. "sigs.k8s.io/controller-runtime/pkg/envtest/komega"
 )
 
@@ -104,4 +108,226 @@ var _ = Describe("DeleteMachine", func() {
 		By("waiting for the ignition secret to be gone")
 		Eventually(Get(ignition)).Should(Satisfy(apierrors.IsNotFound))
 	})
+
+	It("should delete or retain a persistent root disk according to its reclaim policy", func(ctx SpecContext) {
+		By("creating an ironcore machine with a persistent root disk")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["rootDisk"] = map[string]interface{}{
+			"volumeClassName": "foo",
+			"size":            "10Gi",
+			"persistent":      true,
+		}
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		machine := &computev1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		rootVolume := &storagev1alpha1.Volume{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0-root",
+			},
+		}
+
+		By("ensuring that the machine references the persistent root volume")
+		Eventually(Object(machine)).Should(HaveField("Spec.Volumes", ContainElement(SatisfyAll(
+			HaveField("Name", v1alpha1.RootDiskVolumeName),
+			HaveField("VolumeSource.VolumeRef", &corev1.LocalObjectReference{Name: rootVolume.Name}),
+		))))
+		Eventually(Object(rootVolume)).Should(HaveField("Spec.DataSource.OSImage.Image", testing.SampleProviderSpec["image"]))
+
+		By("deleting the machine with the retain reclaim policy")
+		providerSpec["rootDisk"].(map[string]interface{})["reclaimPolicy"] = v1alpha1.ReclaimPolicyRetain
+		Expect((*drv).DeleteMachine(ctx, &driver.DeleteMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.DeleteMachineResponse{}))
+
+		By("ensuring that the persistent root volume is retained")
+		Eventually(Get(machine)).Should(Satisfy(apierrors.IsNotFound))
+		Consistently(Get(rootVolume)).Should(Succeed())
+
+		By("failing to reuse the persistent root volume from another machine class")
+		otherMachineClass := newMachineClass(v1alpha1.ProviderName, providerSpec)
+		otherMachineClass.Name = "other"
+		_, err := (*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: otherMachineClass,
+			Secret:       providerSecret,
+		})
+		Expect(err).To(MatchError(ContainSubstring("belongs to another machine class")))
+		Consistently(Get(machine)).Should(Satisfy(apierrors.IsNotFound))
+
+		By("recreating the machine and reusing the persistent root volume")
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(HaveField("NodeName", "machine-0"))
+		Eventually(Object(machine)).Should(HaveField("Spec.Volumes", ContainElement(
+			HaveField("VolumeSource.VolumeRef", &corev1.LocalObjectReference{Name: rootVolume.Name}),
+		)))
+
+		By("deleting the machine with the delete reclaim policy")
+		providerSpec["rootDisk"].(map[string]interface{})["reclaimPolicy"] = v1alpha1.ReclaimPolicyDelete
+		Expect((*drv).DeleteMachine(ctx, &driver.DeleteMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.DeleteMachineResponse{}))
+
+		By("waiting for the persistent root volume to be gone")
+		Eventually(Get(rootVolume)).Should(Satisfy(apierrors.IsNotFound))
+	})
+
+	It("should delete the ironcore machine of the provider ID of the machine", func(ctx SpecContext) {
+		By("creating an ironcore machine")
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       providerSecret,
+		})).To(HaveField("NodeName", "machine-0"))
+
+		machine := &computev1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		ignition := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      machine.Name,
+			},
+		}
+
+		By("deleting a renamed machine with a provider ID")
+		renamedMachine := newMachine(ns, "renamed", -1, nil)
+		renamedMachine.Spec.ProviderID = fmt.Sprintf("%s://%s/machine-0", v1alpha1.ProviderName, ns.Name)
+		Expect((*drv).DeleteMachine(ctx, &driver.DeleteMachineRequest{
+			Machine:      renamedMachine,
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.DeleteMachineResponse{}))
+
+		By("waiting for the machine and the ignition secret to be gone")
+		Eventually(Get(machine)).Should(Satisfy(apierrors.IsNotFound))
+		Eventually(Get(ignition)).Should(Satisfy(apierrors.IsNotFound))
+	})
+
+	type deleteState struct {
+		machine, ignition, rootVolume, networkInterface bool
+	}
+
+	var deleteStateEntries []TableEntry
+	for i := range 16 {
+		state := deleteState{
+			machine:          i&1 != 0,
+			ignition:         i&2 != 0,
+			rootVolume:       i&4 != 0,
+			networkInterface: i&8 != 0,
+		}
+		deleteStateEntries = append(deleteStateEntries, Entry(fmt.Sprintf("%+v", state), state))
+	}
+
+	DescribeTable("should clean up the leftovers of a machine and succeed",
+		func(ctx SpecContext, state deleteState) {
+			providerSpec := testing.Copy(testing.SampleProviderSpec)
+			providerSpec["rootDisk"] = map[string]interface{}{
+				"volumeClassName": "foo",
+				"size":            "10Gi",
+				"persistent":      true,
+			}
+			machineClass := newMachineClass(v1alpha1.ProviderName, providerSpec)
+
+			machine := &computev1alpha1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: ns.Name,
+					Name:      "machine-0",
+				},
+			}
+			ignition := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: ns.Name,
+					Name:      machine.Name,
+				},
+			}
+			rootVolume := &storagev1alpha1.Volume{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: ns.Name,
+					Name:      "machine-0-root",
+				},
+			}
+			networkInterface := &networkingv1alpha1.NetworkInterface{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: ns.Name,
+					Name:      "machine-0-primary",
+					Labels:    getObjectLabels(machine.Name, machineClass, nil),
+				},
+				Spec: networkingv1alpha1.NetworkInterfaceSpec{
+					NetworkRef: corev1.LocalObjectReference{Name: "my-network"},
+					IPFamilies: []corev1.IPFamily{corev1.IPv4Protocol},
+					IPs:        []networkingv1alpha1.IPSource{{Value: commonv1alpha1.MustParseNewIP("10.0.0.1")}},
+				},
+			}
+			otherNetworkInterface := &networkingv1alpha1.NetworkInterface{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: ns.Name,
+					Name:      "machine-1-primary",
+					Labels:    getObjectLabels("machine-1", machineClass, nil),
+				},
+				Spec: networkInterface.Spec,
+			}
+
+			By("creating the machine and deleting the objects which are not part of the state")
+			Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+				Machine:      newMachine(ns, "machine", -1, nil),
+				MachineClass: machineClass,
+				Secret:       providerSecret,
+			})).To(HaveField("NodeName", machine.Name))
+			Expect(k8sClient.Create(ctx, networkInterface)).To(Succeed())
+			Expect(k8sClient.Create(ctx, otherNetworkInterface)).To(Succeed())
+			for obj, exists := range map[client.Object]bool{
+				machine:          state.machine,
+				ignition:         state.ignition,
+				rootVolume:       state.rootVolume,
+				networkInterface: state.networkInterface,
+			} {
+				if !exists {
+					Expect(k8sClient.Delete(ctx, obj)).To(Succeed())
+					Eventually(Get(obj)).Should(Satisfy(apierrors.IsNotFound))
+				}
+			}
+
+			By("deleting the machine twice")
+			for range 2 {
+				Expect((*drv).DeleteMachine(ctx, &driver.DeleteMachineRequest{
+					Machine:      newMachine(ns, "machine", -1, nil),
+					MachineClass: machineClass,
+					Secret:       providerSecret,
+				})).To(Equal(&driver.DeleteMachineResponse{}))
+			}
+
+			By("ensuring that all objects of the machine are gone")
+			Eventually(Get(machine)).Should(Satisfy(apierrors.IsNotFound))
+			Eventually(Get(ignition)).Should(Satisfy(apierrors.IsNotFound))
+			Eventually(Get(rootVolume)).Should(Satisfy(apierrors.IsNotFound))
+			Eventually(Get(networkInterface)).Should(Satisfy(apierrors.IsNotFound))
+
+			By("ensuring that the network interface of another machine is kept")
+			Consistently(Get(otherNetworkInterface)).Should(Succeed())
+		},
+		deleteStateEntries,
+	)
 })
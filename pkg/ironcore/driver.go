@@ -5,8 +5,11 @@ package ironcore
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"strings"
 
+	machinev1alpha1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
@@ -14,8 +17,12 @@ import (
 	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
 
 const (
@@ -23,6 +30,21 @@ const (
 	ShootNameLabelKey      = "shoot-name"
 	ShootNamespaceLabelKey = "shoot-namespace"
 	DefaultCSIDriverName   = "csi.ironcore.dev"
+	// IgnitionHashAnnotation is the content hash of the ignition. On the ignition Secret it reflects the current
+	// content, on the Machine the content the Machine was created with.
+	IgnitionHashAnnotation = "mcm.ironcore.de/ignition-hash"
+	// MachineNameLabel is set on every created ironcore object and identifies the MCM machine it belongs to.
+	MachineNameLabel = "mcm.ironcore.de/machine-name"
+	// MachineClassLabel is set on every created ironcore object and identifies the MCM machine class it was created from.
+	MachineClassLabel = "mcm.ironcore.de/machine-class"
+	// MachineNamespaceLabel is set on every created ironcore object and identifies the MCM namespace of the machine class.
+	MachineNamespaceLabel = "mcm.ironcore.de/machine-namespace"
+	// MachineNameAnnotation is set on the ironcore Machine and contains the name of the MCM machine, which differs from
+	// the name of the ironcore Machine if the provider spec defines a naming. Unlike the MachineNameLabel it is never
+	// replaced by a hash.
+	MachineNameAnnotation = "mcm.ironcore.de/mcm-machine-name"
+
+	eventSourceComponent = "machine-controller-manager-provider-ironcore"
 )
 
 var (
@@ -34,18 +56,22 @@ type ironcoreDriver struct {
 	IroncoreClient    client.Client
 	IroncoreNamespace string
 	CSIDriverName     string
+
+	clientPool *clientPool
 }
 
 func (d *ironcoreDriver) InitializeMachine(ctx context.Context, request *driver.InitializeMachineRequest) (*driver.InitializeMachineResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "IronCore Provider does not yet implement InitializeMachine")
 }
 
-// NewDriver returns a new Gardener ironcore driver object
-func NewDriver(c client.Client, namespace, csiDriverName string) driver.Driver {
+// NewDriver returns a new Gardener ironcore driver object. The client options are used for the clients created from
+// the credentials of machine class secrets.
+func NewDriver(c client.Client, namespace, csiDriverName string, clientOpts ClientOptions) driver.Driver {
 	return &ironcoreDriver{
 		IroncoreClient:    c,
 		IroncoreNamespace: namespace,
 		CSIDriverName:     csiDriverName,
+		clientPool:        newClientPool(c.Scheme(), clientOpts),
 	}
 }
 
@@ -62,6 +88,173 @@ func (d *ironcoreDriver) getIgnitionNameForMachine(ctx context.Context, machineN
 	return ignitionSecretName
 }
 
+// getRootVolumeNameForMachine returns the name of the persistent root disk Volume of the machine.
+func getRootVolumeNameForMachine(machineName string) string {
+	return fmt.Sprintf("%s-%s", machineName, v1alpha1.RootDiskVolumeName)
+}
+
+// recordEvent records an event for the given ironcore object. The event is named after the object, the reason and
+// the message, so that repeated calls, e.g. on retries, increase the count of the existing event instead of creating
+// new ones. Errors are only logged as events are informational.
+func (d *ironcoreDriver) recordEvent(ctx context.Context, obj client.Object, eventType, reason, message string) {
+	event, err := d.newEvent(obj, eventType, reason, message)
+	if err != nil {
+		klog.Errorf("Failed to determine kind of %s to record event: %v", client.ObjectKeyFromObject(obj), err)
+		return
+	}
+	event.Name = fmt.Sprintf("%s.%s", obj.GetName(), shortHash(ignitionHash([]byte(reason+"\n"+message))))
+
+	err = d.IroncoreClient.Create(ctx, event)
+	if apierrors.IsAlreadyExists(err) {
+		err = d.bumpEvent(ctx, client.ObjectKeyFromObject(event))
+	}
+	if err != nil {
+		klog.Errorf("Failed to record event %s for %s: %v", reason, client.ObjectKeyFromObject(obj), err)
+	}
+}
+
+// bumpEvent increases the count of an existing event and updates its last timestamp.
+func (d *ironcoreDriver) bumpEvent(ctx context.Context, key client.ObjectKey) error {
+	event := &corev1.Event{}
+	if err := d.IroncoreClient.Get(ctx, key, event); err != nil {
+		return err
+	}
+	base := event.DeepCopy()
+	event.Count++
+	event.LastTimestamp = metav1.Now()
+	return d.IroncoreClient.Patch(ctx, event, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+}
+
+// recordEventOnce creates an event for the given ironcore object unless an event with the same key was already
+// recorded. It is used for conditions which are detected repeatedly, e.g. on every status request.
+func (d *ironcoreDriver) recordEventOnce(ctx context.Context, obj client.Object, key, eventType, reason, message string) {
+	event, err := d.newEvent(obj, eventType, reason, message)
+	if err != nil {
+		klog.Errorf("Failed to determine kind of %s to record event: %v", client.ObjectKeyFromObject(obj), err)
+		return
+	}
+	event.Name = fmt.Sprintf("%s.%s", obj.GetName(), key)
+
+	if err := d.IroncoreClient.Create(ctx, event); err != nil && !apierrors.IsAlreadyExists(err) {
+		klog.Errorf("Failed to record event %s for %s: %v", reason, client.ObjectKeyFromObject(obj), err)
+	}
+}
+
+func (d *ironcoreDriver) newEvent(obj client.Object, eventType, reason, message string) (*corev1.Event, error) {
+	gvk, err := apiutil.GVKForObject(obj, d.IroncoreClient.Scheme())
+	if err != nil {
+		return nil, err
+	}
+
+	now := metav1.Now()
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: obj.GetNamespace(),
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion:      gvk.GroupVersion().String(),
+			Kind:            gvk.Kind,
+			Name:            obj.GetName(),
+			Namespace:       obj.GetNamespace(),
+			UID:             obj.GetUID(),
+			ResourceVersion: obj.GetResourceVersion(),
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: eventSourceComponent},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}, nil
+}
+
+// ignitionHash returns the content hash of the given ignition which is used to detect ignition changes.
+func ignitionHash(ignition []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(ignition))
+}
+
+// getObjectLabels returns the labels of every ironcore object created for a machine: the labels of the provider
+// spec and the provider labels identifying the MCM machine and machine class.
+func getObjectLabels(machineName string, machineClass *machinev1alpha1.MachineClass, labels map[string]string) map[string]string {
+	objectLabels := make(map[string]string, len(labels)+3)
+	for key, value := range labels {
+		objectLabels[key] = value
+	}
+	for key, value := range getMachineClassLabels(machineClass) {
+		objectLabels[key] = value
+	}
+	objectLabels[MachineNameLabel] = labelValue(machineName)
+	return objectLabels
+}
+
+// getMachineClassLabels returns the provider labels identifying the ironcore Machines of the given machine class.
+func getMachineClassLabels(machineClass *machinev1alpha1.MachineClass) map[string]string {
+	return map[string]string{
+		MachineClassLabel:     labelValue(machineClass.Name),
+		MachineNamespaceLabel: labelValue(machineClass.Namespace),
+	}
+}
+
+// labelValue returns the given value if it is a valid label value, otherwise its hash. Object names can be longer
+// than the 63 characters allowed for label values.
+func labelValue(value string) string {
+	if len(validation.IsValidLabelValue(value)) == 0 {
+		return value
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(value)))[:validation.LabelValueMaxLength]
+}
+
 func getProviderIDForIroncoreMachine(ironcoreMachine *computev1alpha1.Machine) string {
 	return fmt.Sprintf("%s://%s/%s", v1alpha1.ProviderName, ironcoreMachine.Namespace, ironcoreMachine.Name)
 }
+
+// parseProviderID returns the key of the ironcore Machine of the given provider ID. Besides the
+// ironcore://<namespace>/<name> format of getProviderIDForIroncoreMachine, ironcore:///<namespace>/<name> is accepted.
+func parseProviderID(providerID string) (client.ObjectKey, error) {
+	scheme, path, ok := strings.Cut(providerID, "://")
+	if !ok || scheme != v1alpha1.ProviderName {
+		return client.ObjectKey{}, fmt.Errorf("provider ID %q does not have the scheme %s://", providerID, v1alpha1.ProviderName)
+	}
+	namespace, name, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok {
+		return client.ObjectKey{}, fmt.Errorf("provider ID %q does not have the format %s://<namespace>/<name>", providerID, v1alpha1.ProviderName)
+	}
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return client.ObjectKey{}, fmt.Errorf("provider ID %q has an invalid namespace: %s", providerID, strings.Join(errs, ", "))
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return client.ObjectKey{}, fmt.Errorf("provider ID %q has an invalid name: %s", providerID, strings.Join(errs, ", "))
+	}
+	return client.ObjectKey{Namespace: namespace, Name: name}, nil
+}
+
+// getIroncoreMachineKey returns the key of the ironcore Machine of the given machine, which is taken from its
+// provider ID, so that the Machine is found independent of the name and namespace of the machine. The namespace of
+// the provider ID is used as is, even if it differs from the namespace of the driver, so that a Machine is still
+// found after the namespace of the machine class secret changed. Legacy machines without a provider ID fall back to
+// the name according to the naming of the provider spec in the namespace of the driver.
+func (d *ironcoreDriver) getIroncoreMachineKey(machine *machinev1alpha1.Machine, providerSpec *v1alpha1.ProviderSpec) (client.ObjectKey, error) {
+	if machine.Spec.ProviderID == "" {
+		name, err := getIroncoreMachineName(machine, providerSpec)
+		if err != nil {
+			return client.ObjectKey{}, err
+		}
+		return client.ObjectKey{Namespace: d.IroncoreNamespace, Name: name}, nil
+	}
+	key, err := parseProviderID(machine.Spec.ProviderID)
+	if err != nil {
+		return client.ObjectKey{}, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to parse provider ID of machine %s: %v", machine.Name, err))
+	}
+	return key, nil
+}
+
+// withNamespace returns the driver for the given ironcore namespace.
+func (d *ironcoreDriver) withNamespace(namespace string) *ironcoreDriver {
+	if namespace == d.IroncoreNamespace {
+		return d
+	}
+	drv := *d
+	drv.IroncoreNamespace = namespace
+	return &drv
+}
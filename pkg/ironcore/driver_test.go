@@ -0,0 +1,32 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ironcore
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Driver", func() {
+	DescribeTable("parseProviderID",
+		func(providerID string, key client.ObjectKey, match types.GomegaMatcher) {
+			parsedKey, err := parseProviderID(providerID)
+			Expect(err).To(match)
+			Expect(parsedKey).To(Equal(key))
+		},
+		Entry("provider ID of the driver", "ironcore://foo/bar", client.ObjectKey{Namespace: "foo", Name: "bar"}, Not(HaveOccurred())),
+		Entry("provider ID with empty host", "ironcore:///foo/bar", client.ObjectKey{Namespace: "foo", Name: "bar"}, Not(HaveOccurred())),
+		Entry("name with dots", "ironcore://foo/bar.baz", client.ObjectKey{Namespace: "foo", Name: "bar.baz"}, Not(HaveOccurred())),
+		Entry("empty provider ID", "", client.ObjectKey{}, MatchError(ContainSubstring("does not have the scheme"))),
+		Entry("other scheme", "aws:///foo/bar", client.ObjectKey{}, MatchError(ContainSubstring("does not have the scheme"))),
+		Entry("missing scheme separator", "ironcore:/foo/bar", client.ObjectKey{}, MatchError(ContainSubstring("does not have the scheme"))),
+		Entry("missing name", "ironcore://foo", client.ObjectKey{}, MatchError(ContainSubstring("does not have the format"))),
+		Entry("empty namespace", "ironcore:////bar", client.ObjectKey{}, MatchError(ContainSubstring("invalid namespace"))),
+		Entry("invalid namespace", "ironcore://Foo/bar", client.ObjectKey{}, MatchError(ContainSubstring("invalid namespace"))),
+		Entry("empty name", "ironcore://foo/", client.ObjectKey{}, MatchError(ContainSubstring("invalid name"))),
+		Entry("additional path segment", "ironcore://foo/bar/baz", client.ObjectKey{}, MatchError(ContainSubstring("invalid name"))),
+	)
+})
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ironcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// apiErrorCode returns the machine-controller-manager status code for an error returned by the ironcore apiserver.
+// The machine controller retries Unknown, DeadlineExceeded, Aborted and Unavailable with a short and all other codes
+// with a long retry period, so transient errors map to the former and permanent errors to the latter.
+func apiErrorCode(err error) codes.Code {
+	var statusErr *status.Status
+	switch {
+	case err == nil:
+		return codes.OK
+	case errors.As(err, &statusErr):
+		return statusErr.Code()
+	case apierrors.IsNotFound(err):
+		return codes.NotFound
+	case apierrors.IsAlreadyExists(err):
+		return codes.AlreadyExists
+	case apierrors.IsConflict(err):
+		return codes.Aborted
+	case apierrors.IsTooManyRequests(err), apierrors.IsServiceUnavailable(err):
+		return codes.Unavailable
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err), errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case apierrors.IsForbidden(err):
+		return codes.PermissionDenied
+	case apierrors.IsUnauthorized(err):
+		return codes.Unauthenticated
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return codes.InvalidArgument
+	case apierrors.IsInternalError(err):
+		return codes.Internal
+	default:
+		// e.g. connection errors, which are likely to be resolved by a retry
+		return codes.Unknown
+	}
+}
+
+// apiError wraps an error returned by the ironcore apiserver into a status error with the code of apiErrorCode and
+// the formatted message as prefix. Status errors are returned as they are.
+func apiError(err error, format string, args ...any) error {
+	var statusErr *status.Status
+	if errors.As(err, &statusErr) {
+		return err
+	}
+	return status.WrapError(apiErrorCode(err), fmt.Sprintf("%s: %v", fmt.Sprintf(format, args...), err), err)
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ironcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+var _ = Describe("Errors", func() {
+	machines := schema.GroupResource{Group: "compute.ironcore.dev", Resource: "machines"}
+	machineKind := schema.GroupKind{Group: "compute.ironcore.dev", Kind: "Machine"}
+
+	DescribeTable("apiErrorCode",
+		func(err error, code codes.Code) {
+			Expect(apiErrorCode(err)).To(Equal(code))
+		},
+		Entry("no error", nil, codes.OK),
+		Entry("status error", status.Error(codes.ResourceExhausted, "foo"), codes.ResourceExhausted),
+		Entry("wrapped status error", fmt.Errorf("foo: %w", status.Error(codes.InvalidArgument, "bar")), codes.InvalidArgument),
+		Entry("not found", apierrors.NewNotFound(machines, "foo"), codes.NotFound),
+		Entry("wrapped not found", fmt.Errorf("foo: %w", apierrors.NewNotFound(machines, "foo")), codes.NotFound),
+		Entry("already exists", apierrors.NewAlreadyExists(machines, "foo"), codes.AlreadyExists),
+		Entry("conflict", apierrors.NewConflict(machines, "foo", errors.New("bar")), codes.Aborted),
+		Entry("too many requests", apierrors.NewTooManyRequests("foo", 1), codes.Unavailable),
+		Entry("service unavailable", apierrors.NewServiceUnavailable("foo"), codes.Unavailable),
+		Entry("timeout", apierrors.NewTimeoutError("foo", 1), codes.DeadlineExceeded),
+		Entry("server timeout", apierrors.NewServerTimeout(machines, "get", 1), codes.DeadlineExceeded),
+		Entry("context deadline exceeded", context.DeadlineExceeded, codes.DeadlineExceeded),
+		Entry("context canceled", context.Canceled, codes.Canceled),
+		Entry("forbidden", apierrors.NewForbidden(machines, "foo", errors.New("bar")), codes.PermissionDenied),
+		Entry("unauthorized", apierrors.NewUnauthorized("foo"), codes.Unauthenticated),
+		Entry("invalid", apierrors.NewInvalid(machineKind, "foo", field.ErrorList{field.Required(field.NewPath("spec"), "")}), codes.InvalidArgument),
+		Entry("bad request", apierrors.NewBadRequest("foo"), codes.InvalidArgument),
+		Entry("internal error", apierrors.NewInternalError(errors.New("foo")), codes.Internal),
+		Entry("unknown error", errors.New("connection refused"), codes.Unknown),
+	)
+
+	It("should wrap api errors into status errors", func() {
+		cause := apierrors.NewConflict(machines, "foo", errors.New("bar"))
+		err := apiError(cause, "failed to apply machine %s", "foo")
+
+		statusErr, ok := status.FromError(err)
+		Expect(ok).To(BeTrue())
+		Expect(statusErr.Code()).To(Equal(codes.Aborted))
+		Expect(statusErr.Message()).To(HavePrefix("failed to apply machine foo: "))
+		Expect(statusErr.Message()).To(ContainSubstring(cause.Error()))
+	})
+
+	It("should keep status errors", func() {
+		err := status.Error(codes.InvalidArgument, "foo")
+		Expect(apiError(err, "bar")).To(BeIdenticalTo(err))
+	})
+
+	It("should return InvalidArgument for an invalid provider spec", func() {
+		_, err := validateProviderSpecAndSecret(newMachineClass("ironcore", map[string]any{}), nil)
+		statusErr, ok := status.FromError(err)
+		Expect(ok).To(BeTrue())
+		Expect(statusErr.Code()).To(Equal(codes.InvalidArgument))
+	})
+})
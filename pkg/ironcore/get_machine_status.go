@@ -5,6 +5,7 @@ package ironcore
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
@@ -12,7 +13,7 @@ import (
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
 	computev1alpha1 "github.com/ironcore-dev/ironcore/api/compute/v1alpha1"
 	apiv1alpha1 "github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -29,25 +30,76 @@ func (d *ironcoreDriver) GetMachineStatus(ctx context.Context, req *driver.GetMa
 	klog.V(3).Infof("Machine status request has been received for %q", req.Machine.Name)
 	defer klog.V(3).Infof("Machine status request has been processed for %q", req.Machine.Name)
 
-	// Fetch machine key from machine request
-	ironcoreMachineKey := client.ObjectKey{
-		Namespace: d.IroncoreNamespace,
-		Name:      req.Machine.Name,
+	// only the naming is needed to locate the machine, so validation rules added later must not fail existing machines
+	providerSpec := &apiv1alpha1.ProviderSpec{}
+	if err := json.Unmarshal(req.MachineClass.ProviderSpec.Raw, providerSpec); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to decode provider spec: %v", err))
+	}
+
+	d, err := d.forSecret(req.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	ironcoreMachineKey, err := d.getIroncoreMachineKey(req.Machine, providerSpec)
+	if err != nil {
+		return nil, err
 	}
 	ironcoreMachine := &computev1alpha1.Machine{}
 	if err := d.IroncoreClient.Get(ctx, ironcoreMachineKey, ironcoreMachine); err != nil {
-		if apierrors.IsNotFound(err) {
-			return nil, status.Error(codes.NotFound, err.Error())
-		}
-		return nil, status.Error(codes.Internal, err.Error())
+		// NotFound signals the machine controller that the machine has to be created
+		return nil, apiError(err, "failed to get ironcore machine %s", ironcoreMachineKey)
 	}
 
+	if err := d.ensureProviderLabels(ctx, ironcoreMachine, req.Machine, req.MachineClass); err != nil {
+		return nil, err
+	}
+
+	d.checkIgnitionDrift(ctx, ironcoreMachine)
+
 	return &driver.GetMachineStatusResponse{
 		ProviderID: getProviderIDForIroncoreMachine(ironcoreMachine),
 		NodeName:   ironcoreMachine.Name,
 	}, nil
 }
 
+// checkIgnitionDrift reports if the ignition of the ironcore Machine changed after the Machine was created. The
+// Machine only evaluates its ignition on first boot, so it has to be replaced to pick up the change. Failing to
+// check the ignition does not fail the status request.
+// The machine controller only requests the status while it creates or deletes a machine, e.g. when CreateMachine is
+// retried with a changed ProviderSpec, and the driver API cannot report drift back to it. The drift is therefore only
+// logged and recorded as event on the ironcore Machine, replacing the machine is up to the operator.
+func (d *ironcoreDriver) checkIgnitionDrift(ctx context.Context, ironcoreMachine *computev1alpha1.Machine) {
+	bootIgnitionHash := ironcoreMachine.Annotations[IgnitionHashAnnotation]
+	if bootIgnitionHash == "" || ironcoreMachine.Spec.IgnitionRef == nil {
+		return
+	}
+
+	ignitionSecret := &corev1.Secret{}
+	ignitionSecretKey := client.ObjectKey{Namespace: ironcoreMachine.Namespace, Name: ironcoreMachine.Spec.IgnitionRef.Name}
+	if err := d.IroncoreClient.Get(ctx, ignitionSecretKey, ignitionSecret); err != nil {
+		klog.Errorf("Failed to get ignition secret %s to check for ignition changes: %v", ignitionSecretKey, err)
+		return
+	}
+
+	currentIgnitionHash := ignitionHash(ignitionSecret.Data[getIgnitionKeyOrDefault(ironcoreMachine.Spec.IgnitionRef.Key)])
+	if currentIgnitionHash == bootIgnitionHash {
+		return
+	}
+
+	message := fmt.Sprintf("Ignition changed after the machine was created (%s -> %s), the machine has to be replaced to apply it", shortHash(bootIgnitionHash), shortHash(currentIgnitionHash))
+	klog.Warningf("Machine %s: %s", ironcoreMachine.Name, message)
+	d.recordEventOnce(ctx, ironcoreMachine, "ignition-outdated-"+shortHash(currentIgnitionHash), corev1.EventTypeWarning, "IgnitionOutdated", message)
+}
+
+// shortHash shortens the given hash for messages and names.
+func shortHash(hash string) string {
+	if len(hash) > 10 {
+		return hash[:10]
+	}
+	return hash
+}
+
 func isEmptyMachineStatusRequest(req *driver.GetMachineStatusRequest) bool {
 	return req == nil || req.MachineClass == nil || req.Machine == nil || req.Secret == nil
 }
@@ -9,10 +9,15 @@ import (
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	computev1alpha1 "github.com/ironcore-dev/ironcore/api/compute/v1alpha1"
 	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
 	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/ironcore/testing"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	. "sigs.k8s.io/controller-runtime/pkg/envtest/komega"
 )
 
 var _ = Describe("GetMachineStatus", func() {
@@ -48,5 +53,118 @@ var _ = Describe("GetMachineStatus", func() {
 			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
 			NodeName:   "machine-0",
 		}))
+
+		By("ensuring the machine status with a provider spec which does not pass the validation")
+		providerSpec := testing.Copy(testing.SampleProviderSpec)
+		providerSpec["sysctls"] = map[string]string{"Foo Bar": "1"}
+		Expect((*drv).GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, providerSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.GetMachineStatusResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+	})
+
+	It("should report an ignition change after the machine was created", func(ctx SpecContext) {
+		By("creating machine")
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.CreateMachineResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0),
+			NodeName:   "machine-0",
+		}))
+
+		By("ensuring that the ignition secret and the machine have the same ignition hash")
+		ignitionSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(ignitionSecret)).Should(HaveField("ObjectMeta.Annotations", HaveKey(IgnitionHashAnnotation)))
+		bootIgnitionHash := ignitionSecret.Annotations[IgnitionHashAnnotation]
+		machine := &computev1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+			},
+		}
+		Eventually(Object(machine)).Should(HaveField("ObjectMeta.Annotations", HaveKeyWithValue(IgnitionHashAnnotation, bootIgnitionHash)))
+
+		By("applying the machine again with changed user data")
+		changedSecret := providerSecret.DeepCopy()
+		changedSecret.Data["userData"] = []byte("changed")
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       changedSecret,
+		})).To(HaveField("NodeName", "machine-0"))
+
+		By("ensuring that only the ignition hash of the secret changed")
+		Eventually(Object(ignitionSecret)).Should(HaveField("ObjectMeta.Annotations", HaveKeyWithValue(IgnitionHashAnnotation, Not(Equal(bootIgnitionHash)))))
+		Consistently(Object(machine)).Should(HaveField("ObjectMeta.Annotations", HaveKeyWithValue(IgnitionHashAnnotation, bootIgnitionHash)))
+
+		By("ensuring that the machine status reports the ignition change")
+		Expect((*drv).GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       changedSecret,
+		})).To(HaveField("NodeName", "machine-0"))
+		Eventually(func(g Gomega) {
+			events := &corev1.EventList{}
+			g.Expect(k8sClient.List(ctx, events, client.InNamespace(ns.Name))).To(Succeed())
+			g.Expect(events.Items).To(ContainElement(SatisfyAll(
+				HaveField("InvolvedObject.Name", "machine-0"),
+				HaveField("Type", corev1.EventTypeWarning),
+				HaveField("Reason", "IgnitionOutdated"),
+			)))
+		}).Should(Succeed())
+	})
+
+	It("should locate the ironcore machine by the provider ID of the machine", func(ctx SpecContext) {
+		By("creating machine")
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       providerSecret,
+		})).To(HaveField("NodeName", "machine-0"))
+
+		By("ensuring the status of a renamed machine with a provider ID")
+		machine := newMachine(ns, "renamed", -1, nil)
+		machine.Spec.ProviderID = fmt.Sprintf("%s:///%s/machine-0", v1alpha1.ProviderName, ns.Name)
+		Expect((*drv).GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+			Machine:      machine,
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       providerSecret,
+		})).To(Equal(&driver.GetMachineStatusResponse{
+			ProviderID: fmt.Sprintf("%s://%s/machine-0", v1alpha1.ProviderName, ns.Name),
+			NodeName:   "machine-0",
+		}))
+
+		By("failing on a provider ID of another provider")
+		machine.Spec.ProviderID = fmt.Sprintf("aws:///%s/machine-0", ns.Name)
+		_, err := (*drv).GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+			Machine:      machine,
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       providerSecret,
+		})
+		statusErr, ok := status.FromError(err)
+		Expect(ok).To(BeTrue())
+		Expect(statusErr.Code()).To(Equal(codes.InvalidArgument))
+
+		By("reporting a machine of a provider ID in another namespace as not found")
+		machine.Spec.ProviderID = fmt.Sprintf("%s://%s-other/machine-0", v1alpha1.ProviderName, ns.Name)
+		_, err = (*drv).GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+			Machine:      machine,
+			MachineClass: newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec),
+			Secret:       providerSecret,
+		})
+		statusErr, ok = status.FromError(err)
+		Expect(ok).To(BeTrue())
+		Expect(statusErr.Code()).To(Equal(codes.NotFound))
 	})
 })
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 
+	machinev1alpha1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
@@ -26,25 +27,77 @@ func (d *ironcoreDriver) ListMachines(ctx context.Context, req *driver.ListMachi
 
 	providerSpec, err := validateProviderSpecAndSecret(req.MachineClass, req.Secret)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("provider spec for requested provider '%s' is invalid: %v", req.MachineClass.Provider, err))
+		return nil, err
+	}
+
+	d, err = d.forSecret(req.Secret)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get ironcore machine list
 	ironcoreMachineList := &computev1alpha1.MachineList{}
-	matchingLabels := client.MatchingLabels{}
-	for k, v := range providerSpec.Labels {
-		matchingLabels[k] = v
+	if err := d.IroncoreClient.List(ctx, ironcoreMachineList, client.InNamespace(d.IroncoreNamespace), client.MatchingLabels(getMachineClassLabels(req.MachineClass))); err != nil {
+		return nil, apiError(err, "failed to list ironcore machines")
 	}
-	if err := d.IroncoreClient.List(ctx, ironcoreMachineList, client.InNamespace(d.IroncoreNamespace), matchingLabels); err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+
+	legacyMachines, err := d.listLegacyMachines(ctx, providerSpec)
+	if err != nil {
+		return nil, err
 	}
 
 	//Creating machineList from ironcoreMachineList items
-	machineList := make(map[string]string, len(ironcoreMachineList.Items))
-	for _, machine := range ironcoreMachineList.Items {
+	machineList := make(map[string]string, len(ironcoreMachineList.Items)+len(legacyMachines))
+	for _, machine := range append(ironcoreMachineList.Items, legacyMachines...) {
 		machineID := getProviderIDForIroncoreMachine(&machine)
-		machineList[machineID] = machine.Name
+		machineList[machineID] = getMachineName(&machine)
 	}
 
 	return &driver.ListMachinesResponse{MachineList: machineList}, nil
 }
+
+// listLegacyMachines returns the ironcore Machines which were created before the provider labels were introduced.
+// Such Machines are identified by the labels of the provider spec, like ListMachines did before. Without labels in the
+// provider spec no Machine is returned, as this would claim every Machine in the namespace. The Machines are not
+// modified, the provider labels are added by ensureProviderLabels once the machine class of a Machine is known.
+func (d *ironcoreDriver) listLegacyMachines(ctx context.Context, providerSpec *apiv1alpha1.ProviderSpec) ([]computev1alpha1.Machine, error) {
+	if len(providerSpec.Labels) == 0 {
+		return nil, nil
+	}
+
+	ironcoreMachineList := &computev1alpha1.MachineList{}
+	if err := d.IroncoreClient.List(ctx, ironcoreMachineList, client.InNamespace(d.IroncoreNamespace), client.MatchingLabels(providerSpec.Labels)); err != nil {
+		return nil, apiError(err, "failed to list ironcore machines by provider spec labels")
+	}
+
+	var legacyMachines []computev1alpha1.Machine
+	for _, machine := range ironcoreMachineList.Items {
+		if _, ok := machine.Labels[MachineClassLabel]; !ok {
+			legacyMachines = append(legacyMachines, machine)
+		}
+	}
+
+	return legacyMachines, nil
+}
+
+// ensureProviderLabels adds the provider labels of the given machine and machine class to an ironcore Machine which
+// was created before the provider labels were introduced.
+func (d *ironcoreDriver) ensureProviderLabels(ctx context.Context, ironcoreMachine *computev1alpha1.Machine, machine *machinev1alpha1.Machine, machineClass *machinev1alpha1.MachineClass) error {
+	if _, ok := ironcoreMachine.Labels[MachineClassLabel]; ok {
+		return nil
+	}
+
+	base := ironcoreMachine.DeepCopy()
+	if ironcoreMachine.Labels == nil {
+		ironcoreMachine.Labels = make(map[string]string)
+	}
+	for key, value := range getObjectLabels(machine.Name, machineClass, nil) {
+		ironcoreMachine.Labels[key] = value
+	}
+	if err := d.IroncoreClient.Patch(ctx, ironcoreMachine, client.MergeFrom(base)); err != nil {
+		return apiError(err, "failed to add provider labels to machine %s", ironcoreMachine.Name)
+	}
+	klog.V(2).Infof("Added provider labels of machine class %s to machine %s", machineClass.Name, ironcoreMachine.Name)
+
+	return nil
+}
@@ -7,10 +7,14 @@ import (
 	"fmt"
 
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/driver"
+	computev1alpha1 "github.com/ironcore-dev/ironcore/api/compute/v1alpha1"
 	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
 	"github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/ironcore/testing"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	. "sigs.k8s.io/controller-runtime/pkg/envtest/komega"
 )
 
 var _ = Describe("ListMachines", func() {
@@ -116,4 +120,96 @@ var _ = Describe("ListMachines", func() {
 			Secret:       providerSecret,
 		})
 	})
+
+	It("should only list the machines of the requested machine class", func(ctx SpecContext) {
+		By("creating a machine")
+		machineClass := newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec)
+		machineClass.Name = "foo"
+		Expect((*drv).CreateMachine(ctx, &driver.CreateMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: machineClass,
+			Secret:       providerSecret,
+		})).To(HaveField("NodeName", "machine-0"))
+		DeferCleanup((*drv).DeleteMachine, &driver.DeleteMachineRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: machineClass,
+			Secret:       providerSecret,
+		})
+
+		By("ensuring the machine is listed for its machine class")
+		listMachinesResponse, err := (*drv).ListMachines(ctx, &driver.ListMachinesRequest{
+			MachineClass: machineClass,
+			Secret:       providerSecret,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listMachinesResponse.MachineList).To(Equal(
+			map[string]string{fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0): "machine-0"},
+		))
+
+		By("ensuring the machine is not listed for another machine class with the same labels")
+		otherMachineClass := newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec)
+		otherMachineClass.Name = "bar"
+		listMachinesResponse, err = (*drv).ListMachines(ctx, &driver.ListMachinesRequest{
+			MachineClass: otherMachineClass,
+			Secret:       providerSecret,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listMachinesResponse.MachineList).To(BeEmpty())
+	})
+
+	It("should list machines created without provider labels", func(ctx SpecContext) {
+		By("creating a machine with the labels of the provider spec only")
+		machine := &computev1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      "machine-0",
+				Labels: map[string]string{
+					"shoot-name":      "my-shoot",
+					"shoot-namespace": "my-shoot-namespace",
+				},
+			},
+			Spec: computev1alpha1.MachineSpec{
+				MachineClassRef: corev1.LocalObjectReference{Name: "machine-class"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, machine)
+
+		By("ensuring the machine is listed for the machine class")
+		machineClass := newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec)
+		machineClass.Name = "foo"
+		listMachinesResponse, err := (*drv).ListMachines(ctx, &driver.ListMachinesRequest{
+			MachineClass: machineClass,
+			Secret:       providerSecret,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listMachinesResponse.MachineList).To(Equal(
+			map[string]string{fmt.Sprintf("%s://%s/machine-%d", v1alpha1.ProviderName, ns.Name, 0): "machine-0"},
+		))
+
+		By("ensuring the machine is not modified by listing it")
+		Consistently(Object(machine)).Should(HaveField("Labels", Not(HaveKey(MachineClassLabel))))
+
+		By("ensuring the provider labels are added to the machine by the machine status")
+		Expect((*drv).GetMachineStatus(ctx, &driver.GetMachineStatusRequest{
+			Machine:      newMachine(ns, "machine", -1, nil),
+			MachineClass: machineClass,
+			Secret:       providerSecret,
+		})).To(HaveField("NodeName", "machine-0"))
+		Eventually(Object(machine)).Should(HaveField("Labels", SatisfyAll(
+			HaveKeyWithValue(MachineNameLabel, "machine-0"),
+			HaveKeyWithValue(MachineClassLabel, "foo"),
+			HaveKeyWithValue(MachineNamespaceLabel, ""),
+		)))
+
+		By("ensuring the adopted machine is not listed for another machine class")
+		otherMachineClass := newMachineClass(v1alpha1.ProviderName, testing.SampleProviderSpec)
+		otherMachineClass.Name = "bar"
+		listMachinesResponse, err = (*drv).ListMachines(ctx, &driver.ListMachinesRequest{
+			MachineClass: otherMachineClass,
+			Secret:       providerSecret,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listMachinesResponse.MachineList).To(BeEmpty())
+	})
 })
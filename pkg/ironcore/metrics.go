@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ironcore
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "mcm"
+	metricsSubsystem = "ironcore"
+
+	reloadResultSuccess = "success"
+	reloadResultFailure = "failure"
+)
+
+var (
+	kubeconfigReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "kubeconfig_reloads_total",
+		Help:      "Number of reloads of the ironcore kubeconfig by result.",
+	}, []string{"result"})
+
+	rateLimiterWait = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "client_rate_limiter_duration_seconds",
+		Help:      "Time requests to the ironcore apiserver waited for the client rate limiter.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	})
+)
+
+func init() {
+	prometheus.MustRegister(kubeconfigReloads, rateLimiterWait)
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ironcore
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"text/template"
+
+	machinev1alpha1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/codes"
+	"github.com/gardener/machine-controller-manager/pkg/util/provider/machinecodes/status"
+	computev1alpha1 "github.com/ironcore-dev/ironcore/api/compute/v1alpha1"
+	apiv1alpha1 "github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// machineNameData is the data the naming template of the provider spec is executed with.
+type machineNameData struct {
+	Name           string
+	Namespace      string
+	ShootName      string
+	ShootNamespace string
+}
+
+// getIroncoreMachineName returns the name of the ironcore Machine of the given machine according to the naming of the
+// provider spec. The objects of the ironcore Machine, e.g. its ignition Secret, are named after it.
+func getIroncoreMachineName(machine *machinev1alpha1.Machine, providerSpec *apiv1alpha1.ProviderSpec) (string, error) {
+	naming := providerSpec.Naming
+	if naming == nil {
+		return machine.Name, nil
+	}
+
+	name := machine.Name
+	if naming.Template != "" {
+		tmpl, err := template.New("name").Option("missingkey=error").Parse(naming.Template)
+		if err != nil {
+			return "", status.Error(codes.InvalidArgument, fmt.Sprintf("failed to parse naming template: %v", err))
+		}
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, machineNameData{
+			Name:           machine.Name,
+			Namespace:      machine.Namespace,
+			ShootName:      providerSpec.Labels[ShootNameLabelKey],
+			ShootNamespace: providerSpec.Labels[ShootNamespaceLabelKey],
+		}); err != nil {
+			return "", status.Error(codes.InvalidArgument, fmt.Sprintf("failed to execute naming template for machine %s: %v", machine.Name, err))
+		}
+		name = sb.String()
+	}
+
+	name = naming.Prefix + name
+	if naming.HashSuffix {
+		// the name is truncated before the hash is appended, the hash keeps truncated names unique
+		if maxLength := apiv1alpha1.MaxMachineNameLength - apiv1alpha1.MachineNameHashSuffixLength; len(name) > maxLength {
+			name = strings.TrimRight(name[:maxLength], "-.")
+		}
+		// the namespace is part of the hash, so that machines of the same name in different namespaces do not collide
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(machine.Namespace+"/"+machine.Name)))
+		name = fmt.Sprintf("%s-%s", name, shortHash(hash))
+	}
+
+	if len(name) > apiv1alpha1.MaxMachineNameLength {
+		return "", status.Error(codes.InvalidArgument, fmt.Sprintf("name %q of the ironcore machine for machine %s must be no more than %d characters", name, machine.Name, apiv1alpha1.MaxMachineNameLength))
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", status.Error(codes.InvalidArgument, fmt.Sprintf("name %q of the ironcore machine for machine %s is invalid: %s", name, machine.Name, strings.Join(errs, ", ")))
+	}
+	return name, nil
+}
+
+// checkMachineNameConflict returns an AlreadyExists error if an ironcore Machine of the given name exists which
+// belongs to another MCM machine. Machines get the same name if the naming of the provider spec drops the part of
+// their names which tells them apart.
+func (d *ironcoreDriver) checkMachineNameConflict(ctx context.Context, machine *machinev1alpha1.Machine, machineName string) error {
+	ironcoreMachine := &computev1alpha1.Machine{}
+	if err := d.IroncoreClient.Get(ctx, client.ObjectKey{Namespace: d.IroncoreNamespace, Name: machineName}, ironcoreMachine); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return apiError(err, "failed to get ironcore machine %s", machineName)
+	}
+
+	if name := getMachineName(ironcoreMachine); name != machine.Name {
+		return status.Error(codes.AlreadyExists, fmt.Sprintf("ironcore machine %s of machine %s already exists for machine %s", machineName, machine.Name, name))
+	}
+	return nil
+}
+
+// getMachineName returns the name of the MCM machine of the given ironcore Machine. Machines created without the
+// MachineNameAnnotation are named like the MCM machine.
+func getMachineName(ironcoreMachine *computev1alpha1.Machine) string {
+	if name := ironcoreMachine.Annotations[MachineNameAnnotation]; name != "" {
+		return name
+	}
+	return ironcoreMachine.Name
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ironcore
+
+import (
+	"strings"
+
+	machinev1alpha1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	computev1alpha1 "github.com/ironcore-dev/ironcore/api/compute/v1alpha1"
+	apiv1alpha1 "github.com/ironcore-dev/machine-controller-manager-provider-ironcore/pkg/api/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Naming", func() {
+	machine := &machinev1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "shoot--foo--bar",
+			Name:      "machine-0",
+		},
+	}
+	labels := map[string]string{
+		ShootNameLabelKey:      "bar",
+		ShootNamespaceLabelKey: "garden-foo",
+	}
+
+	DescribeTable("getIroncoreMachineName",
+		func(naming *apiv1alpha1.MachineNaming, match types.GomegaMatcher) {
+			name, err := getIroncoreMachineName(machine, &apiv1alpha1.ProviderSpec{Labels: labels, Naming: naming})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(match)
+		},
+		Entry("no naming", nil, Equal("machine-0")),
+		Entry("empty naming", &apiv1alpha1.MachineNaming{}, Equal("machine-0")),
+		Entry("prefix", &apiv1alpha1.MachineNaming{Prefix: "mcm-"}, Equal("mcm-machine-0")),
+		Entry("template", &apiv1alpha1.MachineNaming{Template: "{{ .ShootNamespace }}-{{ .ShootName }}-{{ .Name }}"}, Equal("garden-foo-bar-machine-0")),
+		Entry("template with namespace", &apiv1alpha1.MachineNaming{Template: "{{ .Namespace }}-{{ .Name }}"}, Equal("shoot--foo--bar-machine-0")),
+		Entry("hash suffix", &apiv1alpha1.MachineNaming{HashSuffix: true}, MatchRegexp(`^machine-0-[0-9a-f]{10}$`)),
+		Entry("prefix, template and hash suffix",
+			&apiv1alpha1.MachineNaming{Prefix: "mcm-", Template: "{{ .ShootName }}-{{ .Name }}", HashSuffix: true},
+			MatchRegexp(`^mcm-bar-machine-0-[0-9a-f]{10}$`),
+		),
+		Entry("truncated name with hash suffix",
+			&apiv1alpha1.MachineNaming{Prefix: strings.Repeat("a", 51), Template: "-{{ .Name }}", HashSuffix: true},
+			MatchRegexp(`^a{51}-[0-9a-f]{10}$`),
+		),
+	)
+
+	It("should include the namespace of the machine in the hash suffix", func() {
+		providerSpec := &apiv1alpha1.ProviderSpec{Naming: &apiv1alpha1.MachineNaming{HashSuffix: true}}
+		name, err := getIroncoreMachineName(machine, providerSpec)
+		Expect(err).NotTo(HaveOccurred())
+
+		otherMachine := machine.DeepCopy()
+		otherMachine.Namespace = "shoot--foo--baz"
+		otherName, err := getIroncoreMachineName(otherMachine, providerSpec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(otherName).NotTo(Equal(name))
+
+		Expect(getIroncoreMachineName(machine, providerSpec)).To(Equal(name))
+	})
+
+	DescribeTable("should fail for an invalid naming",
+		func(naming *apiv1alpha1.MachineNaming, errMessage string) {
+			_, err := getIroncoreMachineName(machine, &apiv1alpha1.ProviderSpec{Labels: labels, Naming: naming})
+			Expect(err).To(MatchError(ContainSubstring(errMessage)))
+		},
+		Entry("unparsable template", &apiv1alpha1.MachineNaming{Template: "{{ .Name "}, "failed to parse naming template"),
+		Entry("unknown template field", &apiv1alpha1.MachineNaming{Template: "{{ .Foo }}"}, "failed to execute naming template"),
+		Entry("invalid name", &apiv1alpha1.MachineNaming{Template: "{{ .Name }}_"}, "is invalid"),
+		Entry("too long name", &apiv1alpha1.MachineNaming{Prefix: strings.Repeat("a", 60)}, "must be no more than 63 characters"),
+	)
+
+	It("should recover the machine name from the ironcore machine", func() {
+		ironcoreMachine := &computev1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "mcm-machine-0",
+				Annotations: map[string]string{MachineNameAnnotation: "machine-0"},
+			},
+		}
+		Expect(getMachineName(ironcoreMachine)).To(Equal("machine-0"))
+
+		By("falling back to the name of ironcore machines without annotation")
+		ironcoreMachine.Annotations = nil
+		Expect(getMachineName(ironcoreMachine)).To(Equal("mcm-machine-0"))
+	})
+})
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ironcore
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// applyClientOptions applies the rate limits, the timeout and the user agent of the client options to the rest config.
+// The rate limiter records how long requests wait for it, so that throttling of the ironcore client is visible
+// independently of other clients.
+func applyClientOptions(restConfig *rest.Config, opts ClientOptions) {
+	if opts.QPS != 0 {
+		restConfig.QPS = opts.QPS
+	}
+	if opts.Burst != 0 {
+		restConfig.Burst = opts.Burst
+	}
+	if opts.Timeout != 0 {
+		restConfig.Timeout = opts.Timeout
+	}
+	if opts.UserAgent != "" {
+		restConfig.UserAgent = opts.UserAgent
+	}
+
+	// a negative QPS disables the rate limiter
+	if restConfig.QPS < 0 || restConfig.RateLimiter != nil {
+		return
+	}
+	qps, burst := restConfig.QPS, restConfig.Burst
+	if qps == 0 {
+		qps = rest.DefaultQPS
+	}
+	if burst == 0 {
+		burst = rest.DefaultBurst
+	}
+	restConfig.RateLimiter = &meteredRateLimiter{RateLimiter: flowcontrol.NewTokenBucketRateLimiter(qps, burst)}
+}
+
+// meteredRateLimiter observes the time spent waiting for the wrapped rate limiter.
+type meteredRateLimiter struct {
+	flowcontrol.RateLimiter
+}
+
+func (l *meteredRateLimiter) Accept() {
+	start := time.Now()
+	l.RateLimiter.Accept()
+	rateLimiterWait.Observe(time.Since(start).Seconds())
+}
+
+func (l *meteredRateLimiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := l.RateLimiter.Wait(ctx)
+	rateLimiterWait.Observe(time.Since(start).Seconds())
+	return err
+}
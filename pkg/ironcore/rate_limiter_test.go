@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ironcore
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/client-go/rest"
+)
+
+var _ = Describe("RateLimiter", func() {
+	rateLimiterWaitCount := func() uint64 {
+		m := &dto.Metric{}
+		Expect(rateLimiterWait.Write(m)).To(Succeed())
+		return m.GetHistogram().GetSampleCount()
+	}
+
+	It("should apply the client options to the rest config", func() {
+		restConfig := &rest.Config{}
+		applyClientOptions(restConfig, ClientOptions{
+			QPS:       50,
+			Burst:     100,
+			Timeout:   10 * time.Second,
+			UserAgent: "foo",
+		})
+		Expect(restConfig.QPS).To(Equal(float32(50)))
+		Expect(restConfig.Burst).To(Equal(100))
+		Expect(restConfig.Timeout).To(Equal(10 * time.Second))
+		Expect(restConfig.UserAgent).To(Equal("foo"))
+		Expect(restConfig.RateLimiter).To(BeAssignableToTypeOf(&meteredRateLimiter{}))
+		Expect(restConfig.RateLimiter.QPS()).To(Equal(float32(50)))
+	})
+
+	It("should keep the client-go defaults for empty client options", func() {
+		restConfig := &rest.Config{}
+		applyClientOptions(restConfig, ClientOptions{})
+		Expect(restConfig.QPS).To(BeZero())
+		Expect(restConfig.Timeout).To(BeZero())
+		Expect(restConfig.UserAgent).To(BeEmpty())
+		Expect(restConfig.RateLimiter.QPS()).To(Equal(rest.DefaultQPS))
+	})
+
+	It("should not rate limit for a negative QPS", func() {
+		restConfig := &rest.Config{}
+		applyClientOptions(restConfig, ClientOptions{QPS: -1})
+		Expect(restConfig.RateLimiter).To(BeNil())
+	})
+
+	It("should record the time waited for the rate limiter", func(ctx SpecContext) {
+		restConfig := &rest.Config{}
+		applyClientOptions(restConfig, ClientOptions{QPS: 10, Burst: 1})
+		count := rateLimiterWaitCount()
+
+		Expect(restConfig.RateLimiter.Wait(ctx)).To(Succeed())
+		Expect(restConfig.RateLimiter.Wait(ctx)).To(Succeed())
+		Expect(rateLimiterWaitCount()).To(Equal(count + 2))
+	})
+})
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ironcore
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReloadingClient is a client for the ironcore cluster of a kubeconfig file. The client is rebuilt when the content
+// of the file changes, e.g. when the credentials are rotated, without restarting the controller.
+type ReloadingClient struct {
+	path      string
+	scheme    *runtime.Scheme
+	opts      ClientOptions
+	namespace string
+
+	hash   string
+	client atomic.Pointer[client.Client]
+	// stop stops the cache of the current client
+	stop context.CancelFunc
+}
+
+var _ client.Client = &ReloadingClient{}
+
+// NewReloadingClient creates a ReloadingClient for the given kubeconfig file and returns it together with the
+// namespace of the kubeconfig. The cache of a cached client runs until the given context is done.
+func NewReloadingClient(ctx context.Context, path string, scheme *runtime.Scheme, opts ClientOptions) (*ReloadingClient, string, error) {
+	kubeconfig, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read ironcore kubeconfig %s: %w", path, err)
+	}
+	clientCtx, stop := context.WithCancel(ctx)
+	c, namespace, err := NewClientFromKubeconfig(clientCtx, kubeconfig, scheme, opts)
+	if err != nil {
+		stop()
+		return nil, "", err
+	}
+
+	r := &ReloadingClient{
+		path:      path,
+		scheme:    scheme,
+		opts:      opts,
+		namespace: namespace,
+		hash:      fmt.Sprintf("%x", sha256.Sum256(kubeconfig)),
+		stop:      stop,
+	}
+	r.client.Store(&c)
+	return r, namespace, nil
+}
+
+// Start checks the kubeconfig file for changes in the given interval until the context is done. An invalid file,
+// e.g. while it is being rewritten, keeps the current client until the file is valid again.
+// The file is polled instead of watched, as mounted Secrets are updated by swapping a symlink of the parent
+// directory, which a watch on the file itself doesn't observe.
+func (r *ReloadingClient) Start(ctx context.Context, interval time.Duration) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.reload(ctx); err != nil {
+			kubeconfigReloads.WithLabelValues(reloadResultFailure).Inc()
+			klog.Errorf("Failed to reload ironcore kubeconfig %s, keeping the current client: %v", r.path, err)
+		}
+	}, interval)
+}
+
+// reload rebuilds the client if the content of the kubeconfig file changed and stops the cache of the replaced client.
+func (r *ReloadingClient) reload(ctx context.Context) error {
+	kubeconfig, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read ironcore kubeconfig: %w", err)
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256(kubeconfig))
+	if hash == r.hash {
+		return nil
+	}
+
+	clientCtx, stop := context.WithCancel(ctx)
+	c, namespace, err := NewClientFromKubeconfig(clientCtx, kubeconfig, r.scheme, r.opts)
+	if err != nil {
+		stop()
+		return err
+	}
+	// the namespace is passed to the driver once, so it cannot change without a restart
+	if namespace != r.namespace {
+		stop()
+		return fmt.Errorf("namespace changed from %q to %q, a restart is required", r.namespace, namespace)
+	}
+
+	r.client.Store(&c)
+	r.stop()
+	r.stop = stop
+	r.hash = hash
+	kubeconfigReloads.WithLabelValues(reloadResultSuccess).Inc()
+	klog.Infof("Reloaded ironcore kubeconfig %s", r.path)
+	return nil
+}
+
+func (r *ReloadingClient) current() client.Client {
+	return *r.client.Load()
+}
+
+func (r *ReloadingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	return r.current().Get(ctx, key, obj, opts...)
+}
+
+func (r *ReloadingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return r.current().List(ctx, list, opts...)
+}
+
+func (r *ReloadingClient) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+	return r.current().Apply(ctx, obj, opts...)
+}
+
+func (r *ReloadingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return r.current().Create(ctx, obj, opts...)
+}
+
+func (r *ReloadingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	return r.current().Delete(ctx, obj, opts...)
+}
+
+func (r *ReloadingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return r.current().Update(ctx, obj, opts...)
+}
+
+func (r *ReloadingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return r.current().Patch(ctx, obj, patch, opts...)
+}
+
+func (r *ReloadingClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	return r.current().DeleteAllOf(ctx, obj, opts...)
+}
+
+func (r *ReloadingClient) Status() client.SubResourceWriter {
+	return r.current().Status()
+}
+
+func (r *ReloadingClient) SubResource(subResource string) client.SubResourceClient {
+	return r.current().SubResource(subResource)
+}
+
+func (r *ReloadingClient) Scheme() *runtime.Scheme {
+	return r.scheme
+}
+
+func (r *ReloadingClient) RESTMapper() meta.RESTMapper {
+	return r.current().RESTMapper()
+}
+
+func (r *ReloadingClient) GroupVersionKindFor(obj runtime.Object) (schema.GroupVersionKind, error) {
+	return r.current().GroupVersionKindFor(obj)
+}
+
+func (r *ReloadingClient) IsObjectNamespaced(obj runtime.Object) (bool, error) {
+	return r.current().IsObjectNamespaced(obj)
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ironcore
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("ReloadingClient", func() {
+	It("should rebuild the client when the kubeconfig changes", func(ctx SpecContext) {
+		By("creating a reloading client for a kubeconfig file")
+		kubeconfigPath := filepath.Join(GinkgoT().TempDir(), "kubeconfig")
+		Expect(os.WriteFile(kubeconfigPath, newKubeconfig(cfg, "foo"), 0600)).To(Succeed())
+		reloadingClient, namespace, err := NewReloadingClient(ctx, kubeconfigPath, scheme.Scheme, ClientOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespace).To(Equal("foo"))
+		Expect(reloadingClient.List(ctx, &corev1.NamespaceList{})).To(Succeed())
+
+		initialClient := reloadingClient.current()
+		successes := testutil.ToFloat64(kubeconfigReloads.WithLabelValues(reloadResultSuccess))
+		failures := testutil.ToFloat64(kubeconfigReloads.WithLabelValues(reloadResultFailure))
+
+		By("starting to watch the kubeconfig file")
+		go reloadingClient.Start(ctx, 100*time.Millisecond)
+
+		By("writing an invalid kubeconfig")
+		Expect(os.WriteFile(kubeconfigPath, []byte("foo: ["), 0600)).To(Succeed())
+		Eventually(func() float64 {
+			return testutil.ToFloat64(kubeconfigReloads.WithLabelValues(reloadResultFailure))
+		}).Should(BeNumerically(">", failures))
+		Expect(reloadingClient.current()).To(BeIdenticalTo(initialClient))
+		Expect(reloadingClient.List(ctx, &corev1.NamespaceList{})).To(Succeed())
+
+		By("writing a kubeconfig with rotated credentials")
+		kubeconfig, err := clientcmd.Load(newKubeconfig(cfg, "foo"))
+		Expect(err).NotTo(HaveOccurred())
+		kubeconfig.AuthInfos["envtest"].Token = "rotated"
+		rotatedKubeconfig, err := clientcmd.Write(*kubeconfig)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(kubeconfigPath, rotatedKubeconfig, 0600)).To(Succeed())
+
+		By("ensuring that the client has been rebuilt")
+		Eventually(func() client.Client {
+			return reloadingClient.current()
+		}).ShouldNot(BeIdenticalTo(initialClient))
+		Expect(testutil.ToFloat64(kubeconfigReloads.WithLabelValues(reloadResultSuccess))).To(Equal(successes + 1))
+	})
+})
@@ -3,7 +3,11 @@
 
 package testing
 
-import "net"
+import (
+	"encoding/base64"
+	"net"
+	"strings"
+)
 
 var (
 	SampleProviderSpec = map[string]interface{}{
@@ -69,6 +73,15 @@ var (
 					},
 					"mode": 493.0,
 				},
+				map[string]interface{}{
+					"overwrite": true,
+					"path":      "/var/lib/ironcore-cloud-config/run.sh",
+					"contents": map[string]interface{}{
+						"source":      "data:;base64," + base64.StdEncoding.EncodeToString([]byte(SampleBootstrapScript)),
+						"compression": "",
+					},
+					"mode": 493.0,
+				},
 				map[string]interface{}{
 					"path": "/etc/systemd/resolved.conf.d/dns.conf",
 					"contents": map[string]interface{}{
@@ -89,10 +102,8 @@ ConditionPathExists=!/var/lib/ironcore-cloud-config/init.done
 
 [Service]
 Type=oneshot
-ExecStart=/var/lib/ironcore-cloud-config/init.sh
-ExecStopPost=touch /var/lib/ironcore-cloud-config/init.done
-Restart=on-failure
-RestartSec=5
+NotifyAccess=all
+ExecStart=/var/lib/ironcore-cloud-config/run.sh
 
 [Install]
 WantedBy=multi-user.target
@@ -103,6 +114,88 @@ WantedBy=multi-user.target
 			},
 		},
 	}
+
+	SampleSSHAuthorizedKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIEg9vBVSM5IKAUjin2f6Hy2gtzLelUoSgwyMITQfcg6N test"
+
+	SampleCACertificate = `-----BEGIN CERTIFICATE-----
+MIIBezCCASGgAwIBAgIUVB4ysD93D5iiH7L7c2RnvnpTgMMwCgYIKoZIzj0EAwIw
+EjEQMA4GA1UEAwwHdGVzdC1jYTAgFw0yNjEwMTkwMjE1MDJaGA8yMTI2MDkyNTAy
+MTUwMlowEjEQMA4GA1UEAwwHdGVzdC1jYTBZMBMGByqGSM49AgEGCCqGSM49AwEH
+A0IABJd9VT1hBsBUHaaShoaXX+uIVywqkcFVMWHrY9elw/r0t5UOuWtCVQwW1fbh
+caXVyU1/cFiObIhtI1KN8hvBdPGjUzBRMB0GA1UdDgQWBBSmCxW+jC7UipuRyOSc
+q6XgvOPWCDAfBgNVHSMEGDAWgBSmCxW+jC7UipuRyOScq6XgvOPWCDAPBgNVHRMB
+Af8EBTADAQH/MAoGCCqGSM49BAMCA0gAMEUCID/316NUpuczo8YhfOuyPCnfAVjr
+mSSRY1MVdLWhS8u+AiEA1R5gZW8u4K5FS9mwJb1YS9bZr1MUKjRxxeoqVLJ66n4=
+-----END CERTIFICATE-----
+`
+
+	SampleCloudConfig = `#cloud-config
+hostname: machine-0
+runcmd:
+- - systemctl
+  - try-restart
+  - systemd-resolved.service
+- /var/lib/ironcore-cloud-config/run.sh
+write_files:
+- content: |-
+    [Resolve]
+    DNS=1.2.3.4
+    DNS=5.6.7.8
+  path: /etc/systemd/resolved.conf.d/dns.conf
+  permissions: "0644"
+- content: abcd
+  path: /var/lib/ironcore-cloud-config/init.sh
+  permissions: "0755"
+- content: |
+` + indent(SampleBootstrapScript, 4) + `  path: /var/lib/ironcore-cloud-config/run.sh
+  permissions: "0755"
+`
+
+	SampleBootstrapScript = `#!/bin/bash
+# Runs the bootstrap script of the machine with retries and reports its progress via the status file, the
+# systemd unit status and the optional callback endpoint.
+set -u
+
+state_dir="/var/lib/ironcore-cloud-config"
+max_attempts=11
+retry_interval=5
+attempt_timeout=0
+callback_url=''
+
+report() {
+  local phase="$1" message="$2"
+
+  echo "${phase}: ${message}"
+  echo "${phase}" > "${state_dir}/init.status"
+  systemd-notify --status="${phase}: ${message}" >/dev/null 2>&1 || true
+
+  if [ -n "${callback_url}" ]; then
+    curl -fsS --max-time 10 -X POST -H "Content-Type: application/json" \
+      -d "{\"hostname\":\"$(hostname)\",\"phase\":\"${phase}\",\"message\":\"${message}\"}" \
+      "${callback_url}" >/dev/null || echo "failed to report bootstrap status to ${callback_url}"
+  fi
+}
+
+for attempt in $(seq 1 "${max_attempts}"); do
+  report Running "attempt ${attempt} of ${max_attempts}"
+
+  timeout "${attempt_timeout}" "${state_dir}/init.sh"
+  rc=$?
+  if [ "${rc}" -eq 0 ]; then
+    touch "${state_dir}/init.done"
+    report Succeeded "attempt ${attempt} of ${max_attempts}"
+    exit 0
+  fi
+
+  echo "bootstrap script failed with exit code ${rc}"
+  if [ "${attempt}" -lt "${max_attempts}" ]; then
+    sleep "${retry_interval}"
+  fi
+done
+
+report Failed "bootstrap script failed after ${max_attempts} attempts"
+exit 1
+`
 )
 
 // copy returns a copy of the input map
@@ -113,3 +206,14 @@ func Copy(m map[string]interface{}) map[string]interface{} {
 	}
 	return mc
 }
+
+// indent indents all non-empty lines of s by n spaces
+func indent(s string, n int) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = strings.Repeat(" ", n) + line
+		}
+	}
+	return strings.Join(lines, "")
+}